@@ -0,0 +1,91 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary
+
+import (
+	"io"
+)
+
+// DataErrReader returns a reader which returns the final data of r together with err, instead of in a separate call
+// returning 0, err. If r ends with io.EOF, err replaces it; pass io.EOF to keep it. Unlike iotest.DataErrReader, an
+// empty p returns 0, nil without reading from r.
+func DataErrReader(r io.Reader, err error) io.Reader {
+	return &dataErrReader{reader: r, err: err}
+}
+
+type dataErrReader struct {
+	reader io.Reader
+	err    error
+	buf    []byte
+	unread []byte
+	done   bool
+}
+
+func (r *dataErrReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Read ahead beyond p, so the final data can be returned together with the error.
+	for len(r.unread) <= len(p) && !r.done {
+		r.fill()
+	}
+
+	n := copy(p, r.unread)
+	r.unread = r.unread[n:]
+	if r.done && len(r.unread) == 0 {
+		return n, r.err
+	}
+	return n, nil
+}
+
+// fill appends the next read from the underlying reader to unread.
+func (r *dataErrReader) fill() {
+	if len(r.buf) == 0 {
+		r.buf = make([]byte, 512)
+	}
+	n, err := r.reader.Read(r.buf)
+	r.unread = append(r.unread, r.buf[:n]...)
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		r.done = true
+	}
+}
+
+// StallReader returns a reader which returns 0, nil on every nth call to Read, and reads from r otherwise. The io.Reader
+// documentation discourages, but does not forbid, returning 0, nil. StallReader panics if n is not positive.
+func StallReader(r io.Reader, n int) io.Reader {
+	if n <= 0 {
+		panic("adversary: StallReader with a non-positive n")
+	}
+	return &stallReader{reader: r, n: n}
+}
+
+type stallReader struct {
+	reader io.Reader
+	n      int
+	calls  int
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	s.calls++
+	if s.calls%s.n == 0 {
+		return 0, nil
+	}
+	return s.reader.Read(p)
+}
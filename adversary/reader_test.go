@@ -0,0 +1,53 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
+)
+
+func TestDataErrReader(t *testing.T) {
+	var content = make([]byte, 4096*10+7)
+	assert.True(t, iosemantic.ImplementsReader(t, adversary.DataErrReader(bytes.NewReader(content), io.EOF)))
+
+	var errFailed = errors.New("failed")
+	var reader = adversary.DataErrReader(bytes.NewReader([]byte("abc")), errFailed)
+	var buf = make([]byte, 2)
+	n, err := reader.Read(buf)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, err)
+	n, err = reader.Read(buf)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, errFailed, err)
+}
+
+func TestStallReader(t *testing.T) {
+	var content = make([]byte, 4096*10)
+	assert.True(t, iosemantic.ImplementsReader(t, adversary.StallReader(bytes.NewReader(content), 3)))
+	assert.True(t, iosemantic.ConsumesReader(t, io.ReadAll, content))
+}
+
+func TestStallReaderNonPositive(t *testing.T) {
+	assert.Panics(t, func() { adversary.StallReader(bytes.NewReader(nil), 0) })
+}
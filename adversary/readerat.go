@@ -0,0 +1,100 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned by Write after a BlockingReaderAt has been closed.
+var ErrClosed = errors.New("adversary: write to closed BlockingReaderAt")
+
+// BlockingReaderAt is an io.ReaderAt over data which arrives over time through Write. As permitted by io.ReaderAt,
+// ReadAt blocks until all of the requested data has been written, or until Close is called, after which the final
+// partial read returns io.EOF.
+//
+// The zero value is an empty BlockingReaderAt ready to use. BlockingReaderAt is safe for concurrent use.
+type BlockingReaderAt struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	data   []byte
+	closed bool
+}
+
+// NewBlockingReaderAt returns an empty BlockingReaderAt.
+func NewBlockingReaderAt() *BlockingReaderAt {
+	return &BlockingReaderAt{}
+}
+
+// init initializes the condition variable, and must be called with mu held.
+func (b *BlockingReaderAt) init() {
+	if b.cond == nil {
+		b.cond = sync.NewCond(&b.mu)
+	}
+}
+
+// Write appends p to the data, unblocking any ReadAt which is now complete.
+func (b *BlockingReaderAt) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.init()
+
+	if b.closed {
+		return 0, ErrClosed
+	}
+	b.data = append(b.data, p...)
+	b.cond.Broadcast()
+	return len(p), nil
+}
+
+// Close marks the data as complete, unblocking every pending ReadAt.
+func (b *BlockingReaderAt) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.init()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// ReadAt reads len(p) bytes at off, blocking until they have been written or until Close is called.
+func (b *BlockingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("adversary: negative offset")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.init()
+
+	for int64(len(b.data)) < off+int64(len(p)) && !b.closed {
+		b.cond.Wait()
+	}
+	if off >= int64(len(b.data)) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, b.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
+)
+
+func TestBlockingReaderAt(t *testing.T) {
+	var length = 4096 * 10
+	var reader = adversary.NewBlockingReaderAt()
+	go func() {
+		for i := 0; i < length; i += 4096 {
+			time.Sleep(time.Millisecond)
+			_, _ = reader.Write(make([]byte, 4096))
+		}
+		_ = reader.Close()
+	}()
+
+	var buf = make([]byte, 5000)
+	n, err := reader.ReadAt(buf, 100)
+	assert.Equal(t, len(buf), n)
+	assert.NoError(t, err)
+
+	assert.True(t, iosemantic.ImplementsReaderAt(t, reader, int64(length)))
+
+	n, err = reader.ReadAt(buf, int64(length-10))
+	assert.Equal(t, 10, n)
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary
+
+import (
+	"io"
+	"testing/iotest"
+)
+
+// FailingWriter returns a writer which writes to w, but fails the nth and every later call to Write with 0, err.
+func FailingWriter(w io.Writer, n int, err error) io.Writer {
+	return &failingWriter{writer: w, n: n, err: err}
+}
+
+type failingWriter struct {
+	writer io.Writer
+	n      int
+	err    error
+	calls  int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.calls++
+	if f.calls >= f.n {
+		return 0, f.err
+	}
+	return f.writer.Write(p)
+}
+
+// PartialWriter returns a writer which writes half of p to w and returns err on the first call to Write with more than
+// one byte, and writes all of p to w afterwards. Callers which recover from the error must continue at the returned n.
+func PartialWriter(w io.Writer, err error) io.Writer {
+	return &partialWriter{writer: w, err: err}
+}
+
+type partialWriter struct {
+	writer  io.Writer
+	err     error
+	partial bool
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	if !w.partial && len(p) > 1 {
+		w.partial = true
+		n, err := w.writer.Write(p[:len(p)/2])
+		if err != nil {
+			return n, err
+		}
+		return n, w.err
+	}
+	return w.writer.Write(p)
+}
+
+// OneByteWriter returns a writer which writes a single byte of p to w per call to Write, and returns
+// io.ErrShortWrite if p is longer.
+func OneByteWriter(w io.Writer) io.Writer {
+	return &oneByteWriter{writer: w}
+}
+
+type oneByteWriter struct {
+	writer io.Writer
+}
+
+func (w *oneByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 1 {
+		n, err := w.writer.Write(p[:1])
+		if err != nil {
+			return n, err
+		}
+		return n, io.ErrShortWrite
+	}
+	return w.writer.Write(p)
+}
+
+// LateErrorWriter returns a writer which writes to w, until the nth call to Write. That call silently discards p while
+// reporting success, like a buffered writer whose flush later fails. Every later call to Write and Close returns err.
+func LateErrorWriter(w io.Writer, n int, err error) io.WriteCloser {
+	return &lateErrorWriter{writer: w, n: n, err: err}
+}
+
+type lateErrorWriter struct {
+	writer io.Writer
+	n      int
+	err    error
+	calls  int
+}
+
+func (l *lateErrorWriter) Write(p []byte) (int, error) {
+	l.calls++
+	switch {
+	case l.calls < l.n:
+		return l.writer.Write(p)
+	case l.calls == l.n:
+		return len(p), nil
+	default:
+		return 0, l.err
+	}
+}
+
+func (l *lateErrorWriter) Close() error {
+	if l.calls >= l.n {
+		return l.err
+	}
+	return nil
+}
+
+// TimeoutWriter returns a writer which returns iotest.ErrTimeout on the first call to Write, and writes to w
+// afterwards. It resembles iotest.TimeoutReader.
+func TimeoutWriter(w io.Writer) io.Writer {
+	return &timeoutWriter{writer: w, err: true}
+}
+
+type timeoutWriter struct {
+	writer io.Writer
+	err    bool
+}
+
+func (t *timeoutWriter) Write(p []byte) (int, error) {
+	if t.err {
+		t.err = false
+		return 0, iotest.ErrTimeout
+	}
+	return t.writer.Write(p)
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adversary_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
+)
+
+var errFailed = errors.New("failed")
+
+func TestFailingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var writer = adversary.FailingWriter(&buf, 2, errFailed)
+	assert.True(t, iosemantic.ImplementsWriter(t, writer))
+
+	n, err := writer.Write([]byte("abc"))
+	assert.Zero(t, n)
+	assert.Equal(t, errFailed, err)
+}
+
+func TestPartialWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var writer = adversary.PartialWriter(&buf, errFailed)
+	n, err := writer.Write([]byte("abcd"))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, errFailed, err)
+
+	assert.True(t, iosemantic.ImplementsWriter(t, writer))
+}
+
+func TestOneByteWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var writer = adversary.OneByteWriter(&buf)
+	n, err := writer.Write([]byte("abc"))
+	assert.Equal(t, 1, n)
+	assert.Equal(t, io.ErrShortWrite, err)
+	assert.Equal(t, "a", buf.String())
+}
+
+func TestLateErrorWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var writer = adversary.LateErrorWriter(&buf, 2, errFailed)
+	for _, p := range []string{"a", "b"} {
+		n, err := writer.Write([]byte(p))
+		assert.Equal(t, 1, n)
+		assert.NoError(t, err)
+	}
+	_, err := writer.Write([]byte("c"))
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, errFailed, writer.Close())
+	assert.Equal(t, "a", buf.String())
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+var defaultAllOpts = AllOpts{}
+
+// AllOpts defines fine tunes controls for the ImplementsAll test.
+type AllOpts struct {
+	// Length is the number of bytes a fresh instance holds, passed to ImplementsReaderAt, and the length passed to
+	// ImplementsWriterAt. Zero skips their parallel calls.
+	Length int64
+}
+
+// builtins are the checks run by ImplementsAll for the io interfaces an implementation implements.
+var builtins = []struct {
+	iface reflect.Type
+	check func(t testing.TB, instance interface{}, opts AllOpts) bool
+}{
+	{reflect.TypeOf((*io.Reader)(nil)).Elem(), func(t testing.TB, instance interface{}, _ AllOpts) bool {
+		return ImplementsReader(t, instance.(io.Reader))
+	}},
+	{reflect.TypeOf((*io.ReaderAt)(nil)).Elem(), func(t testing.TB, instance interface{}, opts AllOpts) bool {
+		return ImplementsReaderAt(t, instance.(io.ReaderAt), opts.Length)
+	}},
+	{reflect.TypeOf((*io.Writer)(nil)).Elem(), func(t testing.TB, instance interface{}, _ AllOpts) bool {
+		return ImplementsWriter(t, instance.(io.Writer))
+	}},
+	{reflect.TypeOf((*io.WriterAt)(nil)).Elem(), func(t testing.TB, instance interface{}, opts AllOpts) bool {
+		return ImplementsWriterAt(t, instance.(io.WriterAt), opts.Length)
+	}},
+	{reflect.TypeOf((*io.ReaderFrom)(nil)).Elem(), func(t testing.TB, instance interface{}, _ AllOpts) bool {
+		return ImplementsReaderFrom(t, instance.(io.ReaderFrom))
+	}},
+	{reflect.TypeOf((*io.WriterTo)(nil)).Elem(), func(t testing.TB, instance interface{}, _ AllOpts) bool {
+		return ImplementsWriterTo(t, instance.(io.WriterTo))
+	}},
+}
+
+// ImplementsAll verifies the implementation returned by factory against the checks of every io interface it
+// implements, and against every registered property whose interface it implements:
+//
+// 1. io.Reader, io.ReaderAt, io.Writer, io.WriterAt, io.ReaderFrom and io.WriterTo are verified by their check.
+// 2. registered properties are verified by their Check, in the order of their registration.
+//
+// Every built-in check is passed a fresh instance, which is closed afterwards if it implements io.Closer. The checks
+// of properties are passed factory, and close the instances they create themselves.
+//
+// Use ImplementsAllOpts for more control over the test suite.
+func ImplementsAll(t testing.TB, factory func() interface{}) bool {
+	return ImplementsAllOpts(t, factory, defaultAllOpts)
+}
+
+// ImplementsAllOpts uses providing options to perform ImplementsAll.
+func ImplementsAllOpts(t testing.TB, factory func() interface{}, opts AllOpts) (ok bool) {
+	defer catch(t, "ImplementsAll", &ok)
+
+	var sample = factory()
+	if sample == nil {
+		checking(t, CallFactory)
+		return failed(t, CallFactory, "factory returned nil")
+	}
+	var typ = reflect.TypeOf(sample)
+	if closer, isCloser := sample.(io.Closer); isCloser {
+		_ = closer.Close()
+	}
+
+	// Every check runs even if a previous one failed, so that every failure is reported.
+	ok = true
+	for _, builtin := range builtins {
+		if !typ.Implements(builtin.iface) {
+			continue
+		}
+		var instance = factory()
+		ok = builtin.check(t, instance, opts) && ok
+		if closer, isCloser := instance.(io.Closer); isCloser {
+			_ = closer.Close()
+		}
+	}
+	for _, p := range registered() {
+		if typ.Implements(p.iface) {
+			ok = p.verify(t, factory) && ok
+		}
+	}
+	return ok
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/reference"
+)
+
+// truncater is a storage backend which can be truncated.
+type truncater interface {
+	io.ReaderAt
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// truncateZeros is a property requiring that a truncater zeroes the range it is extended by.
+var truncateZeros = iosemantic.Property{
+	Clause:    "file.truncate-zeros",
+	Interface: (*truncater)(nil),
+	Summary:   "Reads after Truncate see zeros where the file was extended.",
+	Check: func(t testing.TB, factory func() interface{}, report iosemantic.Reporter) bool {
+		var file = factory().(truncater)
+		if _, err := file.WriteAt(bytes.Repeat([]byte{0xff}, 8), 0); err != nil {
+			return report("WriteAt failed", "%v", err)
+		}
+		if err := file.Truncate(4); err != nil {
+			return report("Truncate failed", "%v", err)
+		}
+		if err := file.Truncate(8); err != nil {
+			return report("Truncate failed", "%v", err)
+		}
+		var buf = make([]byte, 8)
+		if _, err := file.ReadAt(buf, 0); err != nil && err != io.EOF {
+			return report("ReadAt failed", "%v", err)
+		}
+		if !bytes.Equal(buf[4:], make([]byte, 4)) {
+			return report("the extended range is not zeroed", "read %v", buf)
+		}
+		return true
+	},
+}
+
+// register registers the property for the duration of the test, so that it does not apply to other tests.
+func register(t *testing.T, p iosemantic.Property) {
+	iosemantic.Register(p)
+	t.Cleanup(func() { iosemantic.Unregister(p.Clause) })
+}
+
+func TestImplementsAll(t *testing.T) {
+	register(t, truncateZeros)
+	iosemantic.UseProfile(t, iosemantic.Minimal)
+	assert.True(t, iosemantic.ImplementsAll(t, func() interface{} { return reference.New("all") }))
+}
+
+func TestImplementsAllOpts(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsAllOpts(t, func() interface{} {
+		return bytes.NewReader(make([]byte, 4096*10))
+	}, iosemantic.AllOpts{Length: 4096 * 10}))
+}
+
+// freshEmpty is a property requiring that fresh instances hold no data.
+var freshEmpty = iosemantic.Property{
+	Clause:    "fresh.empty",
+	Interface: (*interface{ Len() int })(nil),
+	Summary:   "A fresh instance holds no data.",
+	Check: func(t testing.TB, factory func() interface{}, report iosemantic.Reporter) bool {
+		if n := factory().(interface{ Len() int }).Len(); n != 0 {
+			return report("a fresh instance holds data", "Len returned %d", n)
+		}
+		return true
+	},
+}
+
+func TestImplementsAllReportsProperties(t *testing.T) {
+	register(t, freshEmpty)
+	var factory = func() interface{} { return bytes.NewReader(make([]byte, 4096*10)) }
+
+	var rec = &recorder{TB: t}
+	assert.False(t, iosemantic.ImplementsAllOpts(rec, factory, iosemantic.AllOpts{Length: 4096 * 10}))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "[fresh.empty] a fresh instance holds data")
+	}
+
+	t.Run("waiver", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Standard, iosemantic.Waiver{
+			Clause:        freshEmpty.Clause,
+			Justification: "the reader is created from existing content",
+		})
+		assert.True(t, iosemantic.ImplementsAllOpts(rec, factory, iosemantic.AllOpts{Length: 4096 * 10}))
+		assert.Empty(t, rec.messages)
+	})
+}
+
+func TestImplementsAllNilFactory(t *testing.T) {
+	var rec = &recorder{TB: t}
+	assert.False(t, iosemantic.ImplementsAll(rec, func() interface{} { return nil }))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "[call.factory] factory returned nil")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package broken_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/djherbis/buffer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
+	"github.com/kaiserkarel/iosemantic/broken"
+)
+
+// recorder records failures reported by a check, instead of failing the test.
+type recorder struct {
+	testing.TB
+	mu       sync.Mutex
+	failed   bool
+	messages []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = true
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Helper() {}
+
+// slowWriter discards p after a delay, giving an observer time to see what the caller does to p.
+type slowWriter struct{}
+
+func (slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(10 * time.Millisecond)
+	return len(p), nil
+}
+
+// resumingReader returns io.EOF once, and data afterwards, which io.Reader allows.
+type resumingReader struct {
+	eof bool
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if !r.eof {
+		r.eof = true
+		return 0, io.EOF
+	}
+	return len(p), nil
+}
+
+// writerAt adapts a writer to io.WriterAt, by passing p through the writer before writing it at the offset.
+type writerAt struct {
+	writer io.Writer
+	at     io.WriterAt
+}
+
+func (w writerAt) WriteAt(p []byte, off int64) (int, error) {
+	if _, err := w.writer.Write(p); err != nil {
+		return 0, err
+	}
+	return w.at.WriteAt(p, off)
+}
+
+func TestChecksFailForBrokenImplementations(t *testing.T) {
+	var length = 4096 * 100
+	var cases = []struct {
+		name   string
+		clause iosemantic.Clause
+		check  func(t testing.TB) bool
+	}{
+		{"ImplementsReader/OverReader", iosemantic.ReaderCount, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, broken.OverReader(bytes.NewReader(make([]byte, length))))
+		}},
+		{"ImplementsReader/UnexpectedEOFReader", iosemantic.ReaderEOF, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, broken.UnexpectedEOFReader(bytes.NewReader(make([]byte, length))))
+		}},
+		{"ImplementsReader/HalfReader", iosemantic.ReaderShort, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, iotest.HalfReader(bytes.NewReader(make([]byte, length))))
+		}},
+		{"ImplementsReaderAt/ShortReaderAt", iosemantic.ReaderAtShort, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderAt(t, broken.ShortReaderAt(bytes.NewReader(make([]byte, length))), int64(length))
+		}},
+		{"ImplementsReaderAt/SeekingReaderAt", iosemantic.ReaderAtOffset, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderAt(t, broken.SeekingReaderAt(bytes.NewReader(make([]byte, length))), int64(length))
+		}},
+		{"ImplementsReader/NoProgressReader", iosemantic.ReaderProgress, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, broken.NoProgressReader())
+		}},
+		{"ImplementsReaderOpts/IgnoredCloseReader", iosemantic.ReaderClose, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderOpts(t, broken.IgnoredCloseReader(rand.Reader),
+				iosemantic.ReaderOpts{BufferSize: 4096, MaxBytes: int64(length), Close: true})
+		}},
+		{"ImplementsReaderOpts/StickyEOF", iosemantic.ReaderStickyEOF, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderOpts(t, &resumingReader{}, iosemantic.ReaderOpts{BufferSize: 4096, StickyEOF: true})
+		}},
+		{"ImplementsWriterOpts/StickyWriteError", iosemantic.WriterStickyError, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriterOpts(t, adversary.PartialWriter(io.Discard, io.ErrShortWrite),
+				iosemantic.WriterOpts{BufferSize: 4096, StickyWriteError: true})
+		}},
+		{"ImplementsReader/WrappedEOFReader", iosemantic.ReaderEOF, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, broken.WrappedEOFReader(bytes.NewReader(make([]byte, length))))
+		}},
+		{"ImplementsWriterWrapper/OpaqueErrorWriter", iosemantic.WriterWrapperError, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriterWrapper(t, func(w io.Writer) io.WriteCloser { return broken.OpaqueErrorWriter(w) })
+		}},
+		{"ImplementsReader/CapacityReader", iosemantic.ReaderCapacity, func(t testing.TB) bool {
+			return iosemantic.ImplementsReader(t, broken.CapacityReader(bytes.NewReader(make([]byte, length))))
+		}},
+		{"ImplementsWriter/AppendingWriter", iosemantic.WriterCapacity, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriter(t, broken.AppendingWriter(io.Discard))
+		}},
+		{"ImplementsWriter/ShortWriter", iosemantic.WriterShort, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriter(t, broken.ShortWriter(io.Discard))
+		}},
+		{"ImplementsWriter/OverWriter", iosemantic.WriterCount, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriter(t, broken.OverWriter(io.Discard))
+		}},
+		{"ImplementsWriterAt/ShortWriterAt", iosemantic.WriterAtShort, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriterAt(t, broken.ShortWriterAt(buffer.New(int64(length))), int64(length))
+		}},
+		{"ImplementsReaderFrom/EOFReaderFrom", iosemantic.ReaderFromError, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderFrom(t, broken.EOFReaderFrom(io.Discard))
+		}},
+		{"ImplementsWriterTo/MiscountingWriterTo", iosemantic.WriterToCount, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriterTo(t, broken.MiscountingWriterTo(bytes.NewBuffer(make([]byte, length))))
+		}},
+		{"ImplementsReaderOpts/RetainingReader", iosemantic.ReaderRetention, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderOpts(t, broken.RetainingReader(bytes.NewReader(make([]byte, length))),
+				iosemantic.ReaderOpts{BufferSize: 4096, DetectRetention: true})
+		}},
+		{"ImplementsWriterOpts/RetainingWriter", iosemantic.WriterRetention, func(t testing.TB) bool {
+			var buf bytes.Buffer
+			return iosemantic.ImplementsWriterOpts(t, broken.RetainingWriter(&buf),
+				iosemantic.WriterOpts{BufferSize: length, DetectRetention: true, Readback: buf.Bytes})
+		}},
+		{"ImplementsReaderAt/PanickingReaderAt", iosemantic.CallNoPanic, func(t testing.TB) bool {
+			return iosemantic.ImplementsReaderAt(t, broken.PanickingReaderAt(bytes.NewReader(make([]byte, length))), int64(length))
+		}},
+		{"ImplementsWriter/ModifyingWriter", iosemantic.WriterModify, func(t testing.TB) bool {
+			return iosemantic.ImplementsWriter(t, broken.ModifyingWriter(io.Discard))
+		}},
+		{"ImplementsWriterAt/ModifyingWriter", iosemantic.WriterAtModify, func(t testing.TB) bool {
+			var buf = buffer.New(int64(length))
+			return iosemantic.ImplementsWriterAt(t, writerAt{broken.ModifyingWriter(io.Discard), buf}, int64(length))
+		}},
+		{"ImplementsWriterOpts/InPlaceWriter", iosemantic.WriterModify, func(t testing.TB) bool {
+			if raceEnabled {
+				t.Skip("the observer races with the writer by design")
+			}
+			return iosemantic.ImplementsWriterOpts(t, broken.InPlaceWriter(slowWriter{}),
+				iosemantic.WriterOpts{BufferSize: 4096, ObserveModification: true})
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var rec = &recorder{TB: t}
+			assert.False(t, c.check(rec), "check passed")
+			assert.True(t, rec.failed, "check did not report a failure")
+			assert.Contains(t, strings.Join(rec.messages, "\n"), "["+string(c.clause)+"]", "violation of another clause")
+		})
+	}
+}
+
+func TestChecksExplainErrorIdentity(t *testing.T) {
+	var rec = &recorder{TB: t}
+	assert.False(t, iosemantic.ImplementsReader(rec, broken.WrappedEOFReader(bytes.NewReader(make([]byte, 4096)))))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "Read returned a wrapped io.EOF")
+	}
+
+	rec = &recorder{TB: t}
+	assert.False(t, iosemantic.ImplementsWriterWrapper(rec, func(w io.Writer) io.WriteCloser { return broken.OpaqueErrorWriter(w) }))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "does not wrap it using %w")
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package broken
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// OverReader returns a reader which reads from r, but reports n > len(p) whenever it fills p.
+func OverReader(r io.Reader) io.Reader {
+	return &overReader{reader: r}
+}
+
+type overReader struct {
+	reader io.Reader
+}
+
+func (o *overReader) Read(p []byte) (int, error) {
+	n, err := o.reader.Read(p)
+	if n > 0 && n == len(p) {
+		n++
+	}
+	return n, err
+}
+
+// UnexpectedEOFReader returns a reader which reads from r, but reports the end of r as io.ErrUnexpectedEOF instead
+// of io.EOF.
+func UnexpectedEOFReader(r io.Reader) io.Reader {
+	return &unexpectedEOFReader{reader: r}
+}
+
+type unexpectedEOFReader struct {
+	reader io.Reader
+}
+
+func (u *unexpectedEOFReader) Read(p []byte) (int, error) {
+	n, err := u.reader.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// ShortReaderAt returns a reader which reads at most half of p from r, without returning an error.
+func ShortReaderAt(r io.ReaderAt) io.ReaderAt {
+	return &shortReaderAt{reader: r}
+}
+
+type shortReaderAt struct {
+	reader io.ReaderAt
+}
+
+func (s *shortReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) > 1 {
+		p = p[:len(p)/2]
+	}
+	n, err := s.reader.ReadAt(p, off)
+	if err == io.EOF && n == len(p) {
+		err = nil
+	}
+	return n, err
+}
+
+// SeekingReaderAt returns a reader whose ReadAt seeks rs to the offset and reads from there, moving the seek offset.
+func SeekingReaderAt(rs io.ReadSeeker) interface {
+	io.ReaderAt
+	io.ReadSeeker
+} {
+	return &seekingReaderAt{rs: rs}
+}
+
+type seekingReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+func (s *seekingReaderAt) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rs.Read(p)
+}
+
+func (s *seekingReaderAt) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rs.Seek(offset, whence)
+}
+
+func (s *seekingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// RetainingReader returns a reader which reads from r, but also copies the data of every call to Read into the p of
+// the previous call, which it retains.
+func RetainingReader(r io.Reader) io.Reader {
+	return &retainingReader{reader: r}
+}
+
+type retainingReader struct {
+	reader io.Reader
+	prev   []byte
+}
+
+func (r *retainingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	copy(r.prev, p[:n])
+	r.prev = p
+	return n, err
+}
+
+// CapacityReader returns a reader which reads from r into all of p[:cap(p)], corrupting the caller's backing array
+// beyond len(p), but reports at most len(p) bytes.
+func CapacityReader(r io.Reader) io.Reader {
+	return &capacityReader{reader: r}
+}
+
+type capacityReader struct {
+	reader io.Reader
+}
+
+func (c *capacityReader) Read(p []byte) (int, error) {
+	var size = len(p)
+	n, err := c.reader.Read(p[:cap(p)])
+	if n > size {
+		n = size
+	}
+	return n, err
+}
+
+// PanickingReaderAt returns a reader which reads from r, but panics when off is odd, as a bug in handling unaligned
+// offsets might.
+func PanickingReaderAt(r io.ReaderAt) io.ReaderAt {
+	return &panickingReaderAt{reader: r}
+}
+
+type panickingReaderAt struct {
+	reader io.ReaderAt
+}
+
+func (p *panickingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off%2 == 1 {
+		panic("broken: unaligned offset")
+	}
+	return p.reader.ReadAt(b, off)
+}
+
+// LeakingReader returns a reader which reads from r, and starts a background goroutine which Close does not stop.
+func LeakingReader(r io.Reader) io.ReadCloser {
+	var l = &leakingReader{reader: r, stop: make(chan struct{})}
+	go l.background()
+	return l
+}
+
+type leakingReader struct {
+	reader io.Reader
+	stop   chan struct{}
+}
+
+func (l *leakingReader) background() {
+	<-l.stop
+}
+
+func (l *leakingReader) Read(p []byte) (int, error) {
+	return l.reader.Read(p)
+}
+
+// Close forgets to close l.stop.
+func (l *leakingReader) Close() error {
+	return nil
+}
+
+// NoProgressReader returns a reader which returns (0, nil) forever, which the io.Reader documentation discourages.
+func NoProgressReader() io.Reader {
+	return noProgressReader{}
+}
+
+type noProgressReader struct{}
+
+func (noProgressReader) Read([]byte) (int, error) {
+	return 0, nil
+}
+
+// IgnoredCloseReader returns a reader which reads from r, and whose Close does nothing, so that Read keeps succeeding
+// after Close.
+func IgnoredCloseReader(r io.Reader) io.ReadCloser {
+	return &ignoredCloseReader{reader: r}
+}
+
+type ignoredCloseReader struct {
+	reader io.Reader
+}
+
+func (i *ignoredCloseReader) Read(p []byte) (int, error) {
+	return i.reader.Read(p)
+}
+
+func (i *ignoredCloseReader) Close() error {
+	return nil
+}
+
+// WrappedEOFReader returns a reader which reads from r, but wraps io.EOF using fmt.Errorf, so that callers comparing
+// with == do not recognize the end of the stream.
+func WrappedEOFReader(r io.Reader) io.Reader {
+	return &wrappedEOFReader{reader: r}
+}
+
+type wrappedEOFReader struct {
+	reader io.Reader
+}
+
+func (w *wrappedEOFReader) Read(p []byte) (int, error) {
+	n, err := w.reader.Read(p)
+	if err == io.EOF {
+		err = fmt.Errorf("broken: %w", err)
+	}
+	return n, err
+}
+
+// FullReader returns a reader which reads from r until p is full, instead of returning the data which is available.
+// io.Reader allows this, but a consumer of a stream which is interactive, such as a network connection, blocks until
+// more data arrives.
+func FullReader(r io.Reader) io.Reader {
+	return &fullReader{reader: r}
+}
+
+type fullReader struct {
+	reader io.Reader
+}
+
+func (f *fullReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(f.reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,212 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package broken
+
+import (
+	"fmt"
+	"io"
+)
+
+// ShortWriter returns a writer which writes half of p to w without returning an error.
+func ShortWriter(w io.Writer) io.Writer {
+	return &shortWriter{writer: w}
+}
+
+type shortWriter struct {
+	writer io.Writer
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	return s.writer.Write(p[:len(p)/2])
+}
+
+// OverWriter returns a writer which writes to w, but reports n > len(p).
+func OverWriter(w io.Writer) io.Writer {
+	return &overWriter{writer: w}
+}
+
+type overWriter struct {
+	writer io.Writer
+}
+
+func (o *overWriter) Write(p []byte) (int, error) {
+	n, err := o.writer.Write(p)
+	return n + 1, err
+}
+
+// ShortWriterAt returns a writer which writes half of p to w without returning an error.
+func ShortWriterAt(w io.WriterAt) io.WriterAt {
+	return &shortWriterAt{writer: w}
+}
+
+type shortWriterAt struct {
+	writer io.WriterAt
+}
+
+func (s *shortWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return s.writer.WriteAt(p[:len(p)/2], off)
+}
+
+// EOFReaderFrom returns a ReaderFrom which copies to w, but returns io.EOF instead of nil once the source is drained.
+func EOFReaderFrom(w io.Writer) io.ReaderFrom {
+	return &eofReaderFrom{writer: w}
+}
+
+type eofReaderFrom struct {
+	writer io.Writer
+}
+
+func (e *eofReaderFrom) ReadFrom(r io.Reader) (int64, error) {
+	var buf = make([]byte, 512)
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		m, werr := e.writer.Write(buf[:n])
+		total += int64(m)
+		if werr != nil {
+			return total, werr
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// MiscountingWriterTo returns a WriterTo which writes using wt, but reports one byte more than written.
+func MiscountingWriterTo(wt io.WriterTo) io.WriterTo {
+	return &miscountingWriterTo{writerTo: wt}
+}
+
+type miscountingWriterTo struct {
+	writerTo io.WriterTo
+}
+
+func (m *miscountingWriterTo) WriteTo(w io.Writer) (int64, error) {
+	n, err := m.writerTo.WriteTo(w)
+	if n > 0 {
+		n++
+	}
+	return n, err
+}
+
+// RetainingWriter returns a writer which retains p instead of copying it, and only writes it to w on Flush.
+func RetainingWriter(w io.Writer) interface {
+	io.Writer
+	Flush() error
+} {
+	return &retainingWriter{writer: w}
+}
+
+type retainingWriter struct {
+	writer  io.Writer
+	pending [][]byte
+}
+
+func (r *retainingWriter) Write(p []byte) (int, error) {
+	r.pending = append(r.pending, p)
+	return len(p), nil
+}
+
+func (r *retainingWriter) Flush() error {
+	for _, p := range r.pending {
+		if _, err := r.writer.Write(p); err != nil {
+			return err
+		}
+	}
+	r.pending = nil
+	return nil
+}
+
+// AppendingWriter returns a writer which appends a terminator to p before writing it to w, corrupting the caller's
+// backing array beyond len(p) when it has spare capacity.
+func AppendingWriter(w io.Writer) io.Writer {
+	return &appendingWriter{writer: w}
+}
+
+type appendingWriter struct {
+	writer io.Writer
+}
+
+func (a *appendingWriter) Write(p []byte) (int, error) {
+	n, err := a.writer.Write(append(p, '\n'))
+	if n > len(p) {
+		n = len(p)
+	}
+	return n, err
+}
+
+// ModifyingWriter returns a writer which writes p to w, and zeroes p afterwards.
+func ModifyingWriter(w io.Writer) io.Writer {
+	return &modifyingWriter{writer: w}
+}
+
+type modifyingWriter struct {
+	writer io.Writer
+}
+
+func (m *modifyingWriter) Write(p []byte) (int, error) {
+	n, err := m.writer.Write(p)
+	for i := range p {
+		p[i] = 0
+	}
+	return n, err
+}
+
+// InPlaceWriter returns a writer which encodes p in place, writes the encoded bytes to w and restores p before
+// returning. The modification is only visible while Write runs.
+func InPlaceWriter(w io.Writer) io.Writer {
+	return &inPlaceWriter{writer: w}
+}
+
+type inPlaceWriter struct {
+	writer io.Writer
+}
+
+func (i *inPlaceWriter) Write(p []byte) (int, error) {
+	for j := range p {
+		p[j] ^= 0xff
+	}
+	n, err := i.writer.Write(p)
+	for j := range p {
+		p[j] ^= 0xff
+	}
+	return n, err
+}
+
+// OpaqueErrorWriter returns a writer which writes to w, but formats the errors of w using %v instead of wrapping them,
+// so that errors.Is no longer matches them. Short writes of w result in io.ErrShortWrite, formatted likewise.
+func OpaqueErrorWriter(w io.Writer) io.WriteCloser {
+	return &opaqueErrorWriter{writer: w}
+}
+
+type opaqueErrorWriter struct {
+	writer io.Writer
+}
+
+func (o *opaqueErrorWriter) Write(p []byte) (int, error) {
+	n, err := o.writer.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		return n, fmt.Errorf("broken: %v", err)
+	}
+	return n, nil
+}
+
+func (o *opaqueErrorWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,614 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import "sync"
+
+// Clause identifies a single property verified by the checks, such as ReaderEOF. Clause IDs are stable: they are used
+// to waive clauses, and every failure is prefixed with the ID of the clause it violates.
+type Clause string
+
+// The clauses of io.Reader.
+const (
+	ReaderCount     Clause = "reader.count"
+	ReaderShort     Clause = "reader.short"
+	ReaderEmpty     Clause = "reader.empty"
+	ReaderEOF       Clause = "reader.eof"
+	ReaderRetention Clause = "reader.retention"
+	ReaderCapacity  Clause = "reader.capacity"
+	ReaderProgress  Clause = "reader.progress"
+	ReaderStickyEOF Clause = "reader.sticky-eof"
+	ReaderClose     Clause = "reader.close"
+)
+
+// The clauses of io.ReaderAt.
+const (
+	ReaderAtCount    Clause = "readerat.count"
+	ReaderAtShort    Clause = "readerat.short"
+	ReaderAtEmpty    Clause = "readerat.empty"
+	ReaderAtEOF      Clause = "readerat.eof"
+	ReaderAtParallel Clause = "readerat.parallel"
+	ReaderAtOffset   Clause = "readerat.offset"
+	ReaderAtCapacity Clause = "readerat.capacity"
+)
+
+// The clauses of io.Writer. They apply to io.StringWriter as well.
+const (
+	WriterCount       Clause = "writer.count"
+	WriterShort       Clause = "writer.short"
+	WriterAccept      Clause = "writer.accept"
+	WriterModify      Clause = "writer.modify"
+	WriterRetention   Clause = "writer.retention"
+	WriterCapacity    Clause = "writer.capacity"
+	WriterStickyError Clause = "writer.sticky-error"
+)
+
+// The clauses of io.WriterAt.
+const (
+	WriterAtCount       Clause = "writerat.count"
+	WriterAtShort       Clause = "writerat.short"
+	WriterAtAccept      Clause = "writerat.accept"
+	WriterAtModify      Clause = "writerat.modify"
+	WriterAtRetention   Clause = "writerat.retention"
+	WriterAtCapacity    Clause = "writerat.capacity"
+	WriterAtStickyError Clause = "writerat.sticky-error"
+	WriterAtParallel    Clause = "writerat.parallel"
+)
+
+// The clauses of io.ReaderFrom and io.WriterTo.
+const (
+	ReaderFromError Clause = "readerfrom.error"
+	ReaderFromCount Clause = "readerfrom.count"
+	WriterToError   Clause = "writerto.error"
+	WriterToCount   Clause = "writerto.count"
+)
+
+// The clauses of io.Seeker, verified by MonitorSeeker.
+const (
+	SeekerNegative Clause = "seeker.negative"
+	SeekerStart    Clause = "seeker.start"
+)
+
+// The clauses of ImplementsReaderWrapper and ImplementsWriterWrapper.
+const (
+	ReaderWrapperOutput        Clause = "readerwrapper.output"
+	ReaderWrapperError         Clause = "readerwrapper.error"
+	ReaderWrapperUnexpectedEOF Clause = "readerwrapper.unexpected-eof"
+	ReaderWrapperAvailable     Clause = "readerwrapper.available"
+	WriterWrapperError         Clause = "writerwrapper.error"
+	WriterWrapperShortWrite    Clause = "writerwrapper.short-write"
+)
+
+// The clauses of ImplementsCodec, ImplementsSplitInvariance, ConsumesReader, ConsumesWriter and DifferentialFile.
+const (
+	CodecRoundTrip     Clause = "codec.round-trip"
+	CodecTruncation    Clause = "codec.truncation"
+	CodecClose         Clause = "codec.close"
+	SplitReader        Clause = "split.reader"
+	SplitWriter        Clause = "split.writer"
+	ConsumerReader     Clause = "consumer.reader"
+	ConsumerWriter     Clause = "consumer.writer"
+	DifferentialResult Clause = "differential.result"
+)
+
+// The clauses which apply to every call into an implementation, and to the implementation as a whole.
+const (
+	CallReturns   Clause = "call.returns"
+	CallNoPanic   Clause = "call.no-panic"
+	CallFactory   Clause = "call.factory"
+	LeakGoroutine Clause = "leak.goroutine"
+	LeakFD        Clause = "leak.fd"
+	LeakClose     Clause = "leak.close"
+)
+
+// ClauseInfo describes a clause of the catalogue returned by Clauses.
+type ClauseInfo struct {
+	ID Clause
+	// Interface is the interface the clause belongs to, such as "io.Reader". It is empty for clauses which apply to
+	// every call.
+	Interface string
+	// Doc is the sentence of the interface's Go documentation the clause comes from. It is empty for conventions which
+	// the documentation does not state.
+	Doc string
+	// Summary describes what the checks verify.
+	Summary string
+	// Profile is the least strict profile enforcing the clause. It is Standard for legacy rules stricter than the
+	// documentation, which only the Standard profile enforces.
+	Profile Profile
+	// Standard is set if the Standard profile, used by tests without a profile, enforces the clause.
+	Standard bool
+}
+
+// clauses are all clauses known to the checks, including registered properties. catalogueMu guards clauses and
+// catalogue.
+var clauses = map[Clause]ClauseInfo{}
+
+var catalogueMu sync.RWMutex
+
+func init() {
+	for _, info := range catalogue {
+		clauses[info.ID] = info
+	}
+}
+
+// catalogue lists the clauses in the order of their declaration, followed by registered properties in the order of
+// their registration.
+var catalogue = []ClauseInfo{
+	{
+		ID:        ReaderCount,
+		Interface: "io.Reader",
+		Doc:       "Read reads up to len(p) bytes into p. It returns the number of bytes read (0 <= n <= len(p)) and any error encountered.",
+		Summary:   "Read returns 0 <= n <= len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderShort,
+		Interface: "io.Reader",
+		Summary:   "A short read without an error is followed by a call returning 0 and an error, unless ShortReads is set.",
+		Profile:   Standard,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderEmpty,
+		Interface: "io.Reader",
+		Doc:       "If len(p) == 0, Read should always return n == 0.",
+		Summary:   "Read with len(p) == 0 returns (0, nil).",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderEOF,
+		Interface: "io.Reader",
+		Doc:       "(Read must return EOF itself, not an error wrapping EOF, because callers will test for EOF using ==.)",
+		Summary:   "An io.EOF returned by Read is io.EOF itself, not an error wrapping it.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderRetention,
+		Interface: "io.Reader",
+		Doc:       "Implementations must not retain p.",
+		Summary:   "Read does not write to p after returning.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderCapacity,
+		Interface: "io.Reader",
+		Summary:   "Read does not write to p[len(p):cap(p)].",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderProgress,
+		Interface: "io.Reader",
+		Doc:       "Implementations of Read are discouraged from returning a zero byte count with a nil error, except when len(p) == 0.",
+		Summary:   "Read does not keep returning (0, nil).",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderStickyEOF,
+		Interface: "io.Reader",
+		Doc:       "The next Read should return 0, EOF.",
+		Summary:   "Once Read returned io.EOF, every later call returns (0, io.EOF).",
+		Profile:   Conventional,
+	},
+	{
+		ID:        ReaderClose,
+		Interface: "io.ReadCloser",
+		Summary:   "Read returns an error after Close.",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtCount,
+		Interface: "io.ReaderAt",
+		Doc:       "ReadAt reads len(p) bytes into p starting at offset off in the underlying input source. It returns the number of bytes read (0 <= n <= len(p)) and any error encountered.",
+		Summary:   "ReadAt returns 0 <= n <= len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtShort,
+		Interface: "io.ReaderAt",
+		Doc:       "When ReadAt returns n < len(p), it returns a non-nil error explaining why more bytes were not returned.",
+		Summary:   "ReadAt returns an error if n < len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtEmpty,
+		Interface: "io.ReaderAt",
+		Summary:   "ReadAt with len(p) == 0 returns (0, nil).",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtEOF,
+		Interface: "io.ReaderAt",
+		Doc:       "If the n = len(p) bytes returned by ReadAt are at the end of the input source, ReadAt may return either err == EOF or err == nil.",
+		Summary:   "An io.EOF returned by ReadAt is io.EOF itself, not an error wrapping it.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtParallel,
+		Interface: "io.ReaderAt",
+		Doc:       "Clients of ReadAt can execute parallel ReadAt calls on the same input source.",
+		Summary:   "Parallel calls to ReadAt succeed.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtOffset,
+		Interface: "io.ReaderAt",
+		Doc:       "If ReadAt is reading from an input source with a seek offset, ReadAt should not affect nor be affected by the underlying seek offset.",
+		Summary:   "ReadAt does not move the seek offset.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderAtCapacity,
+		Interface: "io.ReaderAt",
+		Summary:   "ReadAt does not write to p[len(p):cap(p)].",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        WriterCount,
+		Interface: "io.Writer",
+		Doc:       "It returns the number of bytes written from p (0 <= n <= len(p)) and any error encountered that caused the write to stop early.",
+		Summary:   "Write returns 0 <= n <= len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterShort,
+		Interface: "io.Writer",
+		Doc:       "Write must return a non-nil error if it returns n < len(p).",
+		Summary:   "Write returns an error if n < len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAccept,
+		Interface: "io.Writer",
+		Doc:       "Write writes len(p) bytes from p to the underlying data stream.",
+		Summary:   "Write and Flush succeed for a well behaved destination.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterModify,
+		Interface: "io.Writer",
+		Doc:       "Write must not modify the slice data, even temporarily.",
+		Summary:   "Write does not modify p.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterRetention,
+		Interface: "io.Writer",
+		Doc:       "Implementations must not retain p.",
+		Summary:   "Write copies p instead of retaining it.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        WriterCapacity,
+		Interface: "io.Writer",
+		Summary:   "Write does not write to p[len(p):cap(p)].",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        WriterStickyError,
+		Interface: "io.Writer",
+		Summary:   "Once Write failed, every later call fails with the same error.",
+		Profile:   Conventional,
+	},
+	{
+		ID:        WriterAtCount,
+		Interface: "io.WriterAt",
+		Doc:       "It returns the number of bytes written from p (0 <= n <= len(p)) and any error encountered that caused the write to stop early.",
+		Summary:   "WriteAt returns 0 <= n <= len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtShort,
+		Interface: "io.WriterAt",
+		Doc:       "WriteAt must return a non-nil error if it returns n < len(p).",
+		Summary:   "WriteAt returns an error if n < len(p).",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtAccept,
+		Interface: "io.WriterAt",
+		Doc:       "WriteAt writes len(p) bytes from p to the underlying data stream at offset off.",
+		Summary:   "WriteAt succeeds for offsets within the length.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtModify,
+		Interface: "io.WriterAt",
+		Summary:   "WriteAt does not modify p.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtRetention,
+		Interface: "io.WriterAt",
+		Doc:       "Implementations must not retain p.",
+		Summary:   "WriteAt copies p instead of retaining it.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtCapacity,
+		Interface: "io.WriterAt",
+		Summary:   "WriteAt does not write to p[len(p):cap(p)].",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        WriterAtStickyError,
+		Interface: "io.WriterAt",
+		Summary:   "Once WriteAt failed, every later call fails with the same error.",
+		Profile:   Conventional,
+	},
+	{
+		ID:        WriterAtParallel,
+		Interface: "io.WriterAt",
+		Doc:       "Clients of WriteAt can execute parallel WriteAt calls on the same destination if the ranges do not overlap.",
+		Summary:   "Parallel calls to WriteAt on distinct ranges succeed.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderFromError,
+		Interface: "io.ReaderFrom",
+		Doc:       "Any error except EOF encountered during the read is also returned.",
+		Summary:   "ReadFrom returns the error of the source.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderFromCount,
+		Interface: "io.ReaderFrom",
+		Doc:       "The return value n is the number of bytes read.",
+		Summary:   "ReadFrom returns the number of bytes read.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterToError,
+		Interface: "io.WriterTo",
+		Doc:       "Any error encountered during the write is also returned.",
+		Summary:   "WriteTo returns the error of the destination.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterToCount,
+		Interface: "io.WriterTo",
+		Doc:       "The return value n is the number of bytes written.",
+		Summary:   "WriteTo returns the number of bytes written.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        SeekerNegative,
+		Interface: "io.Seeker",
+		Doc:       "Seeking to an offset before the start of the file is an error.",
+		Summary:   "Seek does not return a negative offset without an error.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        SeekerStart,
+		Interface: "io.Seeker",
+		Doc:       "Seek returns the new offset relative to the start of the file or an error, if any.",
+		Summary:   "Seek relative to the start returns the offset.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderWrapperOutput,
+		Interface: "io.Reader",
+		Summary:   "The output of a wrapped reader does not depend on how the source splits its data.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderWrapperError,
+		Interface: "io.Reader",
+		Summary:   "A wrapped reader returns the error of its source.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderWrapperUnexpectedEOF,
+		Interface: "io.Reader",
+		Doc:       "ErrUnexpectedEOF means that EOF was encountered in the middle of reading a fixed-size block or data structure.",
+		Summary:   "A wrapped reader returns io.ErrUnexpectedEOF if its source ends early.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ReaderWrapperAvailable,
+		Interface: "io.Reader",
+		Doc:       "If some data is available but not len(p) bytes, Read conventionally returns what is available instead of waiting for more.",
+		Summary:   "A wrapped reader returns the data which is available.",
+		Profile:   Conventional,
+	},
+	{
+		ID:        WriterWrapperError,
+		Interface: "io.Writer",
+		Summary:   "A wrapped writer returns the error of its destination.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        WriterWrapperShortWrite,
+		Interface: "io.Writer",
+		Doc:       "ErrShortWrite means that a write accepted fewer bytes than requested but failed to return an explicit error.",
+		Summary:   "A wrapped writer returns io.ErrShortWrite for a destination accepting part of p.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        CodecRoundTrip,
+		Interface: "ImplementsCodec",
+		Summary:   "Decoding the encoded content returns the content.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        CodecTruncation,
+		Interface: "ImplementsCodec",
+		Doc:       "ErrUnexpectedEOF means that EOF was encountered in the middle of reading a fixed-size block or data structure.",
+		Summary:   "Decoding truncated content returns io.ErrUnexpectedEOF.",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        CodecClose,
+		Interface: "ImplementsCodec",
+		Summary:   "Content encoded without calling Close does not decode.",
+		Profile:   Conventional,
+		Standard:  true,
+	},
+	{
+		ID:        SplitReader,
+		Interface: "io.Reader",
+		Summary:   "The output of Read does not depend on the lengths of p.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        SplitWriter,
+		Interface: "io.Writer",
+		Summary:   "The output of Write does not depend on how p is split across calls.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ConsumerReader,
+		Interface: "io.Reader",
+		Doc:       "Callers should always process the n > 0 bytes returned before considering the error err.",
+		Summary:   "A consumer handles every legal behaviour of its reader.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        ConsumerWriter,
+		Interface: "io.Writer",
+		Summary:   "A consumer returns the errors of its writer, and does not write p twice.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        DifferentialResult,
+		Interface: "iosemantic.File",
+		Summary:   "Every operation returns what an *os.File returns.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        CallReturns,
+		Interface: "",
+		Summary:   "Every call returns within the timeout.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        CallNoPanic,
+		Interface: "",
+		Summary:   "No call panics.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        CallFactory,
+		Interface: "",
+		Summary:   "A factory passed to a check returns an implementation, not nil.",
+		Profile:   Minimal,
+		Standard:  true,
+	},
+	{
+		ID:        LeakGoroutine,
+		Interface: "io.Closer",
+		Summary:   "No goroutine outlives Close.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        LeakFD,
+		Interface: "io.Closer",
+		Summary:   "No file descriptor outlives Close.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+	{
+		ID:        LeakClose,
+		Interface: "io.Closer",
+		Summary:   "Close of the implementation returned by a leak suite succeeds.",
+		Profile:   Paranoid,
+		Standard:  true,
+	},
+}
+
+// Clauses returns the catalogue of all clauses verified by the checks, ordered by interface, followed by the
+// registered properties.
+func Clauses() []ClauseInfo {
+	catalogueMu.RLock()
+	defer catalogueMu.RUnlock()
+	return append([]ClauseInfo(nil), catalogue...)
+}
+
+// lookup returns the clause from the catalogue, and whether it exists.
+func lookup(c Clause) (ClauseInfo, bool) {
+	catalogueMu.RLock()
+	defer catalogueMu.RUnlock()
+	info, ok := clauses[c]
+	return info, ok
+}
+
+// prefixes maps the methods called by the checks to the prefix of their clauses.
+var prefixes = map[string]string{
+	"Read":        "reader",
+	"ReadAt":      "readerat",
+	"Write":       "writer",
+	"WriteString": "writer",
+	"WriteAt":     "writerat",
+	"ReadFrom":    "readerfrom",
+	"WriteTo":     "writerto",
+	"Seek":        "seeker",
+}
+
+// clauseOf returns the clause named rule of the interface of op, such as ReaderAtCapacity for ("ReadAt", "capacity").
+func clauseOf(op, rule string) Clause {
+	var c = Clause(prefixes[op] + "." + rule)
+	if _, ok := lookup(c); !ok {
+		panic("iosemantic: unknown clause " + c)
+	}
+	return c
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func TestClauses(t *testing.T) {
+	var seen = make(map[iosemantic.Clause]bool)
+	for _, info := range iosemantic.Clauses() {
+		assert.False(t, seen[info.ID], "%s is listed twice", info.ID)
+		seen[info.ID] = true
+
+		assert.NotEmpty(t, info.Summary, "%s has no summary", info.ID)
+		assert.True(t, info.Profile <= iosemantic.Paranoid, "%s is enforced by %s", info.ID, info.Profile)
+		if info.Profile == iosemantic.Standard {
+			assert.True(t, info.Standard, "%s is enforced by no profile", info.ID)
+		}
+		if info.Doc != "" {
+			assert.True(t, strings.HasSuffix(info.Doc, ".") || strings.HasSuffix(info.Doc, ".)"),
+				"the doc of %s is not a sentence", info.ID)
+		}
+		if !strings.HasPrefix(string(info.ID), "call.") {
+			assert.NotEmpty(t, info.Interface, "%s has no interface", info.ID)
+		}
+	}
+}
+
+func TestClausesListsEveryClause(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "clause.go", nil, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var listed = make(map[string]bool)
+	for _, info := range iosemantic.Clauses() {
+		listed[string(info.ID)] = true
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		if ident, ok := spec.Type.(*ast.Ident); !ok || ident.Name != "Clause" {
+			return true
+		}
+		for _, value := range spec.Values {
+			var id = strings.Trim(value.(*ast.BasicLit).Value, `"`)
+			assert.True(t, listed[id], "%s is not in the catalogue", id)
+		}
+		return true
+	})
+}
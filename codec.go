@@ -0,0 +1,222 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var defaultCodecOpts = CodecOpts{
+	Size:        4096 * 10,
+	Truncations: 32,
+}
+
+// ImplementsCodec verifies the following properties for an encoder and its matching decoder:
+//
+// 1. decoding the encoded content returns the original content, for every content generator and buffer length.
+// 2. a truncated encoded stream results in io.ErrUnexpectedEOF, rather than io.EOF, either from newDecoder or Read.
+// 3. the output written before Close does not decode to the complete content.
+// 4. the encoder passes ImplementsWriter, and the decoder passes ImplementsReader.
+//
+// The buffer lengths are swept for both the buffers passed to Write and to Read.
+// Use ImplementsCodecOpts for more control over the test suite.
+func ImplementsCodec(t testing.TB, newEncoder func(io.Writer) io.WriteCloser, newDecoder func(io.Reader) (io.Reader, error)) bool {
+	return ImplementsCodecOpts(t, newEncoder, newDecoder, defaultCodecOpts)
+}
+
+// CodecOpts defines fine tunes controls for the ImplementsCodecOpts test. Unset fields are set to defaults.
+type CodecOpts struct {
+	// Size is the length of the generated content.
+	Size int
+	// Truncations is the maximum number of truncation points tested per encoded stream.
+	Truncations int
+	// Timeout is the deadline of a single call into the encoder or decoder, defaulting to 10 seconds. A call which
+	// does not return fails with a dump of all goroutines, and the check continues with the next stream. A negative
+	// Timeout disables the deadline.
+	Timeout time.Duration
+}
+
+// ImplementsCodecOpts uses providing options to perform ImplementsCodec.
+func ImplementsCodecOpts(t testing.TB, newEncoder func(io.Writer) io.WriteCloser, newDecoder func(io.Reader) (io.Reader, error), opts CodecOpts) (ok bool) {
+	defer catch(t, "ImplementsCodecOpts", &ok)
+
+	if opts.Size <= 0 {
+		opts.Size = defaultCodecOpts.Size
+	}
+	if opts.Truncations <= 0 {
+		opts.Truncations = defaultCodecOpts.Truncations
+	}
+	opts.Timeout = timeoutOf(opts.Timeout)
+
+	// A stream whose encoder or decoder panics or does not return is skipped, and the check continues with the next,
+	// so that every hang is reported.
+	ok = true
+	var rnd = rand.New(rand.NewSource(1))
+	for _, gen := range contentGenerators {
+		var content = gen.generate(rnd, opts.Size)
+		for _, size := range bufferSizes {
+			encoded, err := encode(t, opts.Timeout, newEncoder, content, size)
+			if err == errViolation {
+				ok = false
+				continue
+			}
+			if !noError(t, CodecRoundTrip, err, "%s content, buffer size %d: encoding failed", gen.name, size) {
+				return false
+			}
+
+			decoded, err := decode(t, opts.Timeout, newDecoder, encoded, size)
+			if err == errViolation {
+				ok = false
+				continue
+			}
+			if !(noError(t, CodecRoundTrip, err, "%s content, buffer size %d: decoding failed", gen.name, size) &&
+				holds(t, CodecRoundTrip, bytes.Equal(content, decoded), "%s content, buffer size %d: decoded content differs", gen.name, size)) {
+				return false
+			}
+		}
+
+		if !(truncatedDecode(t, newDecoder, newEncoder, gen.name, content, opts) &&
+			closeRequired(t, newDecoder, newEncoder, gen.name, content, opts.Timeout)) {
+			return false
+		}
+	}
+
+	var encoder = newEncoder(io.Discard)
+	var closeErr error
+	ok = ImplementsWriterOpts(t, encoder, WriterOpts{BufferSize: defaultWriterOpts.BufferSize, Timeout: opts.Timeout}) &&
+		within(t, opts.Timeout, "Close", func() { closeErr = encoder.Close() }, "") &&
+		noError(t, CodecRoundTrip, closeErr, "closing the encoder failed") && ok
+
+	encoded, err := encode(t, opts.Timeout, newEncoder, make([]byte, defaultReaderOpts.BufferSize*100), defaultReaderOpts.BufferSize)
+	if err == errViolation || !noError(t, CodecRoundTrip, err, "encoding failed") {
+		return false
+	}
+	var decoder io.Reader
+	if !within(t, opts.Timeout, "newDecoder", func() { decoder, err = newDecoder(bytes.NewReader(encoded)) }, "%d bytes", len(encoded)) {
+		return false
+	}
+	return noError(t, CodecRoundTrip, err, "creating the decoder failed") &&
+		ImplementsReaderOpts(t, decoder, ReaderOpts{BufferSize: defaultReaderOpts.BufferSize, Timeout: opts.Timeout}) && ok
+}
+
+// truncatedDecode verifies that decoding a truncated encoding of content fails with io.ErrUnexpectedEOF.
+func truncatedDecode(t testing.TB, newDecoder func(io.Reader) (io.Reader, error), newEncoder func(io.Writer) io.WriteCloser, name string, content []byte, opts CodecOpts) bool {
+	encoded, err := encode(t, opts.Timeout, newEncoder, content, len(content))
+	if err == errViolation || !noError(t, CodecRoundTrip, err, "%s content: encoding failed", name) {
+		return false
+	}
+
+	var ok = true
+	var step = 1
+	if len(encoded) > opts.Truncations {
+		step = len(encoded) / opts.Truncations
+	}
+	for end := len(encoded) - 1; end > 0; end -= step {
+		_, err := decode(t, opts.Timeout, newDecoder, encoded[:end], defaultReaderOpts.BufferSize)
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !checkIs(t, CodecTruncation, err, io.ErrUnexpectedEOF,
+			"%s content truncated to %d of %d bytes: expected io.ErrUnexpectedEOF, got %v", name, end, len(encoded), err) {
+			return false
+		}
+	}
+	return ok
+}
+
+// closeRequired verifies that the output of the encoder before Close does not decode to content. Empty content is
+// skipped, as a codec may decode an empty, unclosed stream to empty output.
+func closeRequired(t testing.TB, newDecoder func(io.Reader) (io.Reader, error), newEncoder func(io.Writer) io.WriteCloser, name string, content []byte, timeout time.Duration) bool {
+	if len(content) == 0 {
+		return true
+	}
+
+	var dst bytes.Buffer
+	var encoder = newEncoder(&dst)
+	var err error
+	if !within(t, timeout, "Write", func() { _, err = encoder.Write(content) }, "len(p)=%d", len(content)) ||
+		!noError(t, CodecRoundTrip, err, "%s content: encoding failed", name) {
+		return false
+	}
+
+	var unclosed = append([]byte(nil), dst.Bytes()...)
+	if !within(t, timeout, "Close", func() { err = encoder.Close() }, "") ||
+		!noError(t, CodecRoundTrip, err, "%s content: closing the encoder failed", name) {
+		return false
+	}
+
+	decoded, err := decode(t, timeout, newDecoder, unclosed, defaultReaderOpts.BufferSize)
+	if err == errViolation {
+		return false
+	}
+	return holds(t, CodecClose, !(err == nil && bytes.Equal(content, decoded)),
+		"%s content: output written before Close decodes to the complete content", name)
+}
+
+// encode writes content to a new encoder in chunks of size bytes, closes it, and returns the output. If a call panics
+// or does not return within timeout, encode reports it and returns errViolation.
+func encode(t testing.TB, timeout time.Duration, newEncoder func(io.Writer) io.WriteCloser, content []byte, size int) ([]byte, error) {
+	var dst bytes.Buffer
+	var encoder = newEncoder(&dst)
+	var n int
+	var err error
+	for len(content) > 0 {
+		var chunk = content
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+		if !within(t, timeout, "Write", func() { n, err = encoder.Write(chunk) }, "len(p)=%d", len(chunk)) {
+			return nil, errViolation
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n < len(chunk) {
+			return nil, io.ErrShortWrite
+		}
+		content = content[n:]
+	}
+	if !within(t, timeout, "Close", func() { err = encoder.Close() }, "") {
+		return nil, errViolation
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dst.Bytes(), nil
+}
+
+// decode reads encoded through a new decoder using buffers of size bytes. A nil error is returned on io.EOF. If a call
+// panics or does not return within timeout, decode reports it and returns errViolation.
+func decode(t testing.TB, timeout time.Duration, newDecoder func(io.Reader) (io.Reader, error), encoded []byte, size int) ([]byte, error) {
+	var decoder io.Reader
+	var err error
+	if !within(t, timeout, "newDecoder", func() { decoder, err = newDecoder(bytes.NewReader(encoded)) }, "%d bytes", len(encoded)) {
+		return nil, errViolation
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := drain(t, timeout, decoder, func() int { return size })
+	if err == io.EOF {
+		err = nil
+	}
+	return decoded, err
+}
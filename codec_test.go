@@ -0,0 +1,102 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func newGzipEncoder(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+func newGzipDecoder(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func TestImplementsCodec(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsCodec(t, newGzipEncoder, newGzipDecoder))
+}
+
+func TestImplementsCodecOpts(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsCodecOpts(t, newGzipEncoder, newGzipDecoder, iosemantic.CodecOpts{Size: 1999, Truncations: 100}))
+}
+
+func TestImplementsCodecOptsDefaults(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsCodecOpts(t, newGzipEncoder, newGzipDecoder, iosemantic.CodecOpts{Size: 100}))
+}
+
+// blockEncoder writes every call to Write as a length prefixed block, and a zero length block on Close.
+type blockEncoder struct {
+	w io.Writer
+}
+
+func (b blockEncoder) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(p)))
+	if _, err := b.w.Write(append(header[:], p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (b blockEncoder) Close() error {
+	_, err := b.w.Write(make([]byte, 4))
+	return err
+}
+
+// newBlockDecoder decodes the blocks written by blockEncoder. It treats an empty stream as empty content, even
+// though it lacks the zero length block written by Close.
+func newBlockDecoder(r io.Reader) (io.Reader, error) {
+	encoded, err := io.ReadAll(r)
+	if err != nil || len(encoded) == 0 {
+		return bytes.NewReader(nil), err
+	}
+
+	var decoded []byte
+	for {
+		if len(encoded) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		var size = int(binary.BigEndian.Uint32(encoded))
+		encoded = encoded[4:]
+		if size == 0 {
+			return bytes.NewReader(decoded), nil
+		}
+		if len(encoded) < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		decoded = append(decoded, encoded[:size]...)
+		encoded = encoded[size:]
+	}
+}
+
+func TestImplementsCodecEmptyUnclosed(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsCodec(t, func(w io.Writer) io.WriteCloser {
+		return blockEncoder{w}
+	}, newBlockDecoder))
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/kaiserkarel/iosemantic/adversary"
+)
+
+var defaultConsumerOpts = ConsumerOpts{
+	Timeout: defaultTimeout,
+}
+
+// ConsumesReader verifies that fn consumes readers correctly. fn is called with readers over content which return
+// short reads, return data together with io.EOF, return data together with a non-EOF error, or interleave (0, nil)
+// results. All of these are legal, and the following properties are verified:
+//
+// 1. the output of fn is identical to its output for a bytes.Reader over content.
+// 2. fn returns no error, unless the reader returned a non-EOF error, in which case it is returned.
+//
+// fn should return its output up to the point of failure together with the error.
+// Use ConsumesReaderOpts for more control over the test suite.
+func ConsumesReader(t testing.TB, fn func(io.Reader) ([]byte, error), content []byte) bool {
+	return ConsumesReaderOpts(t, fn, content, defaultConsumerOpts)
+}
+
+// ConsumerOpts defines fine tunes controls for the ConsumesReaderOpts and ConsumesWriterOpts tests. Unset fields are
+// set to defaults.
+type ConsumerOpts struct {
+	// Timeout is the deadline of a single call to fn, defaulting to 10 seconds. A call which does not return fails
+	// with a dump of all goroutines, and the check continues with the next reader or writer. A negative Timeout
+	// disables the deadline.
+	Timeout time.Duration
+}
+
+// ConsumesReaderOpts uses providing options to perform ConsumesReader.
+func ConsumesReaderOpts(t testing.TB, fn func(io.Reader) ([]byte, error), content []byte, opts ConsumerOpts) (ok bool) {
+	defer catch(t, "ConsumesReaderOpts", &ok)
+
+	var timeout = timeoutOf(opts.Timeout)
+	// A call which does not return keeps writing its results in the background, so they are not named results.
+	var consume = func(reader io.Reader, name string) ([]byte, error) {
+		var out []byte
+		var err error
+		if !within(t, timeout, "fn", func() { out, err = fn(reader) }, "%s", name) {
+			return nil, errViolation
+		}
+		return out, err
+	}
+
+	// The outputs are compared against want, so nothing is left to verify if consuming a bytes.Reader failed.
+	want, err := consume(bytes.NewReader(content), "bytes.Reader")
+	if err == errViolation || !noError(t, ConsumerReader, err, "consuming a bytes.Reader") {
+		return false
+	}
+
+	var readers = []struct {
+		name string
+		new  func() io.Reader
+	}{
+		{"iotest.OneByteReader", func() io.Reader { return iotest.OneByteReader(bytes.NewReader(content)) }},
+		{"iotest.HalfReader", func() io.Reader { return iotest.HalfReader(bytes.NewReader(content)) }},
+		{"adversary.DataErrReader", func() io.Reader { return adversary.DataErrReader(bytes.NewReader(content), io.EOF) }},
+		{"adversary.StallReader", func() io.Reader { return adversary.StallReader(iotest.HalfReader(bytes.NewReader(content)), 2) }},
+	}
+	ok = true
+	for _, reader := range readers {
+		got, err := consume(reader.new(), reader.name)
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !(noError(t, ConsumerReader, err, "consuming %s", reader.name) &&
+			holds(t, ConsumerReader, bytes.Equal(want, got), "consuming %s: output differs", reader.name)) {
+			return false
+		}
+	}
+
+	got, err := consume(adversary.DataErrReader(iotest.HalfReader(bytes.NewReader(content)), errSource), "adversary.DataErrReader")
+	return err != errViolation &&
+		checkIs(t, ConsumerReader, err, errSource, "consuming a reader returning data together with an error: expected the reader's error, got %v", err) &&
+		holds(t, ConsumerReader, bytes.Equal(want, got), "consuming a reader returning data together with an error: output differs") && ok
+}
+
+// ConsumesWriter verifies that fn handles failing writers correctly. fn is called with writers which fail on the
+// first, second or third call, accept part of p and return an error, or accept a single byte per call and return
+// io.ErrShortWrite. The following properties are verified:
+//
+// 1. fn returns the writer's error, matched using errors.Is, if the writer failed.
+// 2. if fn recovers from a partial write, the output is identical to its output to a bytes.Buffer.
+//
+// The second property catches callers which ignore the n returned alongside an error, and write p again.
+// Use ConsumesWriterOpts for more control over the test suite.
+func ConsumesWriter(t testing.TB, fn func(io.Writer) error) bool {
+	return ConsumesWriterOpts(t, fn, defaultConsumerOpts)
+}
+
+// ConsumesWriterOpts uses providing options to perform ConsumesWriter.
+func ConsumesWriterOpts(t testing.TB, fn func(io.Writer) error, opts ConsumerOpts) (ok bool) {
+	defer catch(t, "ConsumesWriterOpts", &ok)
+
+	var timeout = timeoutOf(opts.Timeout)
+	var consume = func(writer io.Writer, name string) error {
+		var err error
+		if !within(t, timeout, "fn", func() { err = fn(writer) }, "%s", name) {
+			return errViolation
+		}
+		return err
+	}
+
+	// The outputs of recovered writes are compared against want, so nothing is left to verify if writing to a
+	// bytes.Buffer failed.
+	var buf bytes.Buffer
+	if err := consume(&buf, "bytes.Buffer"); err == errViolation || !noError(t, ConsumerWriter, err, "writing to a bytes.Buffer") {
+		return false
+	}
+	var want = buf.Bytes()
+
+	ok = true
+	for n := 1; n <= 3; n++ {
+		var writer = &observedWriter{writer: adversary.FailingWriter(io.Discard, n, errDestination)}
+		err := consume(writer, fmt.Sprintf("a writer failing on call %d", n))
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if writer.failed && !checkIs(t, ConsumerWriter, err, errDestination,
+			"writing to a writer failing on call %d: expected the writer's error, got %v", n, err) {
+			return false
+		}
+	}
+
+	var writers = []struct {
+		name string
+		new  func(w io.Writer) io.Writer
+		want error
+	}{
+		{"a writer accepting part of p and returning an error", func(w io.Writer) io.Writer {
+			return adversary.PartialWriter(w, errDestination)
+		}, errDestination},
+		{"a writer accepting one byte per call", adversary.OneByteWriter, io.ErrShortWrite},
+	}
+	for _, writer := range writers {
+		var buf bytes.Buffer
+		var dst = &observedWriter{writer: writer.new(&buf)}
+		err := consume(dst, writer.name)
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !dst.failed {
+			continue
+		}
+		if err != nil {
+			if !checkIs(t, ConsumerWriter, err, writer.want, "writing to %s: expected the writer's error, got %v", writer.name, err) {
+				return false
+			}
+			continue
+		}
+		if !holds(t, ConsumerWriter, bytes.Equal(want, buf.Bytes()), "writing to %s: recovered, but the output differs", writer.name) {
+			return false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func TestConsumesReader(t *testing.T) {
+	assert.True(t, iosemantic.ConsumesReader(t, io.ReadAll, bytes.Repeat([]byte("iosemantic"), 1000)))
+}
+
+func TestConsumesReaderLines(t *testing.T) {
+	var lines = func(r io.Reader) ([]byte, error) {
+		var out []byte
+		var scanner = bufio.NewScanner(r)
+		for scanner.Scan() {
+			out = append(out, scanner.Bytes()...)
+		}
+		return out, scanner.Err()
+	}
+	assert.True(t, iosemantic.ConsumesReader(t, lines, bytes.Repeat([]byte("io\nsemantic\n"), 1000)))
+}
+
+func TestConsumesWriter(t *testing.T) {
+	var content = bytes.Repeat([]byte("iosemantic"), 1000)
+	assert.True(t, iosemantic.ConsumesWriter(t, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(content))
+		return err
+	}))
+}
+
+func TestConsumesWriterRetries(t *testing.T) {
+	var content = bytes.Repeat([]byte("iosemantic"), 1000)
+	assert.True(t, iosemantic.ConsumesWriter(t, func(w io.Writer) error {
+		for p := content; len(p) > 0; {
+			n, err := w.Write(p)
+			if err != nil && err != io.ErrShortWrite {
+				return err
+			}
+			p = p[n:]
+		}
+		return nil
+	}))
+}
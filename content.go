@@ -0,0 +1,49 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+// contentGenerator produces content of the given size, used by checks which round-trip data.
+type contentGenerator struct {
+	name     string
+	generate func(rnd *rand.Rand, size int) []byte
+}
+
+// contentGenerators cover content which is trivially compressible, incompressible, and somewhere in between.
+var contentGenerators = []contentGenerator{
+	{"empty", func(*rand.Rand, int) []byte { return []byte{} }},
+	{"zeros", func(_ *rand.Rand, size int) []byte { return make([]byte, size) }},
+	{"random", func(rnd *rand.Rand, size int) []byte {
+		var buf = make([]byte, size)
+		rnd.Read(buf)
+		return buf
+	}},
+	{"text", func(rnd *rand.Rand, size int) []byte {
+		var words = [][]byte{[]byte("io "), []byte("semantic "), []byte("reader "), []byte("writer\n")}
+		var buf bytes.Buffer
+		for buf.Len() < size {
+			buf.Write(words[rnd.Intn(len(words))])
+		}
+		return buf.Bytes()[:size]
+	}},
+}
+
+// bufferSizes is the sweep of buffer lengths used by checks which round-trip data.
+var bufferSizes = []int{1, 7, 512, 4096, 65536}
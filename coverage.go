@@ -0,0 +1,69 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// checking records that the clauses are verified for t, for the coverage summary of its session.
+func checking(t testing.TB, cs ...Clause) {
+	sessionOf(t).check(cs...)
+}
+
+// check records that the clauses are verified.
+func (s *session) check(cs ...Clause) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range cs {
+		s.checked[c] = true
+	}
+}
+
+// coverage summarizes the clauses of the catalogue for the session. A clause was checked if a check verified it and
+// the profile enforces it, waived if the session waives it, and skipped otherwise: no check verified it, or the
+// profile only logs its violations.
+func (s *session) coverage() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var checked, skipped, waived []string
+	for _, info := range Clauses() {
+		switch _, ok := s.waivers[info.ID]; {
+		case ok:
+			waived = append(waived, string(info.ID))
+		case s.checked[info.ID] && s.profile.enforces(info.ID):
+			checked = append(checked, string(info.ID))
+		default:
+			skipped = append(skipped, string(info.ID))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "coverage of the %s profile: %d clauses checked, %d skipped, %d waived",
+		s.profile, len(checked), len(skipped), len(waived))
+	for _, group := range []struct {
+		name    string
+		clauses []string
+	}{{"checked", checked}, {"skipped", skipped}, {"waived", waived}} {
+		if len(group.clauses) > 0 {
+			fmt.Fprintf(&b, "\n%s: %s", group.name, strings.Join(group.clauses, ", "))
+		}
+	}
+	return b.String()
+}
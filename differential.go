@@ -0,0 +1,305 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+)
+
+// File is the set of file methods compared by DifferentialFile. *os.File implements File.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.ReaderAt
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// DifferentialOpts defines fine tunes controls for the DifferentialFile test. Unset fields are set to defaults.
+type DifferentialOpts struct {
+	// Seed seeds the random operation stream, so that every run performs the same operations and a failure in CI
+	// reproduces locally.
+	Seed int64
+	// RandomSeed derives the seed from the current time instead, if Seed is zero, to explore a different operation
+	// stream on every run. The seed is logged, so that a failure can be reproduced by setting Seed.
+	RandomSeed bool
+	// Operations is the number of operations performed, defaulting to 1000.
+	Operations int
+	// BufferSize is the maximum length of the buffer passed to a single call, defaulting to 4096.
+	BufferSize int
+	// MaxOffset bounds the offsets and sizes passed to Seek, ReadAt, WriteAt and Truncate, defaulting to 1 << 16.
+	MaxOffset int64
+	// Timeout is the deadline of a single call into file, defaulting to 10 seconds. A call which does not return fails
+	// with a dump of all goroutines. The operations after it are skipped, as the state of file is unknown, but Close
+	// is still compared. A negative Timeout disables the deadline.
+	Timeout time.Duration
+}
+
+// sentinels are the errors whose identity is compared by DifferentialFile using errors.Is.
+var sentinels = []error{
+	io.EOF,
+	io.ErrUnexpectedEOF,
+	io.ErrShortWrite,
+	os.ErrClosed,
+	os.ErrExist,
+	os.ErrNotExist,
+	os.ErrPermission,
+}
+
+// DifferentialFile uses a real *os.File in t.TempDir() as the oracle for file. It drives the same random stream of
+// Read, Write, Seek, ReadAt, WriteAt and Truncate calls into both, including seeks beyond the end of the file and
+// writes which leave holes, and verifies that:
+//
+// 1. the returned counts and offsets are equal.
+// 2. either both or neither return an error, and errors.Is matches the same io and os sentinel errors for both.
+// 3. the bytes read are equal.
+// 4. the content of both files is equal after the last operation.
+// 5. if file implements io.Closer, Close behaves like the oracle's Close.
+//
+// factory is called once, and must return an empty file.
+func DifferentialFile(t testing.TB, factory func() File, opts DifferentialOpts) (ok bool) {
+	defer catch(t, "DifferentialFile", &ok)
+
+	if opts.Seed == 0 && opts.RandomSeed {
+		opts.Seed = time.Now().UnixNano()
+	}
+	if opts.Operations == 0 {
+		opts.Operations = 1000
+	}
+	if opts.BufferSize == 0 {
+		opts.BufferSize = 4096
+	}
+	if opts.MaxOffset == 0 {
+		opts.MaxOffset = 1 << 16
+	}
+	opts.Timeout = timeoutOf(opts.Timeout)
+	t.Logf("DifferentialFile seed: %d", opts.Seed)
+
+	oracle, err := os.CreateTemp(t.TempDir(), "oracle")
+	if err != nil {
+		return failed(t, DifferentialResult, "creating the *os.File oracle failed", "%v", err)
+	}
+	defer oracle.Close()
+
+	var file = factory()
+	if ok = applyFileOps(t, oracle, file, opts); ok {
+		want, _ := fileContent(t, oracle, 0)
+		got, read := fileContent(t, file, opts.Timeout)
+		if !(read && holds(t, DifferentialResult, bytes.Equal(want, got), "content differs after %d operations", opts.Operations)) {
+			return false
+		}
+	}
+
+	// Close is compared even if an operation failed or did not return, so that every failure is reported.
+	if closer, isCloser := file.(io.Closer); isCloser {
+		for _, name := range []string{"Close", "second Close"} {
+			var want = fileResult{err: oracle.Close()}
+			var got fileResult
+			if !within(t, opts.Timeout, "Close", func() { got.err = closer.Close() }, "") {
+				return false
+			}
+			if !compareFileResults(t, name, want, got) {
+				return false
+			}
+		}
+	}
+	return ok
+}
+
+// applyFileOps drives the random stream of operations into the oracle and file, and compares their results. It stops
+// at the first operation whose results differ, or which panics or does not return within opts.Timeout.
+func applyFileOps(t testing.TB, oracle *os.File, file File, opts DifferentialOpts) bool {
+	var rnd = rand.New(rand.NewSource(opts.Seed))
+	for i := 0; i < opts.Operations; i++ {
+		var op = randomFileOp(rnd, opts)
+		var want = op.apply(oracle)
+		var got fileResult
+		if !within(t, opts.Timeout, op.kind, func() { got = op.apply(file) }, "%s", op.args()) {
+			return false
+		}
+		if !compareFileResults(t, fmt.Sprintf("operation %d: %s", i, op), want, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileOp is a single call made by DifferentialFile.
+type fileOp struct {
+	kind   string
+	data   []byte
+	size   int
+	offset int64
+	whence int
+}
+
+// fileResult holds the results of applying a fileOp.
+type fileResult struct {
+	n    int64
+	data []byte
+	err  error
+}
+
+// randomFileOp returns a random fileOp. Offsets and sizes are occasionally negative.
+func randomFileOp(rnd *rand.Rand, opts DifferentialOpts) fileOp {
+	var op = fileOp{
+		size:   rnd.Intn(opts.BufferSize + 1),
+		offset: rnd.Int63n(opts.MaxOffset + 1),
+	}
+	if rnd.Intn(16) == 0 {
+		op.offset = -op.offset - 1
+	}
+
+	switch rnd.Intn(6) {
+	case 0:
+		op.kind = "Read"
+	case 1:
+		op.kind = "Write"
+		op.data = make([]byte, op.size)
+		rnd.Read(op.data)
+	case 2:
+		op.kind = "Seek"
+		op.whence = rnd.Intn(3)
+		if op.whence != io.SeekStart {
+			op.offset -= opts.MaxOffset / 2
+		}
+	case 3:
+		op.kind = "ReadAt"
+	case 4:
+		op.kind = "WriteAt"
+		op.data = make([]byte, op.size)
+		rnd.Read(op.data)
+	case 5:
+		op.kind = "Truncate"
+	}
+	return op
+}
+
+func (op fileOp) String() string {
+	return op.kind + "(" + op.args() + ")"
+}
+
+// args describes the arguments of op.
+func (op fileOp) args() string {
+	switch op.kind {
+	case "Read", "Write":
+		return fmt.Sprintf("len(p) = %d", op.size)
+	case "Seek":
+		return fmt.Sprintf("%d, %d", op.offset, op.whence)
+	case "ReadAt", "WriteAt":
+		return fmt.Sprintf("len(p) = %d, %d", op.size, op.offset)
+	default:
+		return fmt.Sprintf("%d", op.offset)
+	}
+}
+
+// apply performs op on file.
+func (op fileOp) apply(file File) fileResult {
+	var res fileResult
+	var n int
+	switch op.kind {
+	case "Read":
+		res.data = make([]byte, op.size)
+		n, res.err = file.Read(res.data)
+		res.n, res.data = int64(n), res.data[:clamp(n, len(res.data))]
+	case "Write":
+		n, res.err = file.Write(op.data)
+		res.n = int64(n)
+	case "Seek":
+		res.n, res.err = file.Seek(op.offset, op.whence)
+	case "ReadAt":
+		res.data = make([]byte, op.size)
+		n, res.err = file.ReadAt(res.data, op.offset)
+		res.n, res.data = int64(n), res.data[:clamp(n, len(res.data))]
+	case "WriteAt":
+		n, res.err = file.WriteAt(op.data, op.offset)
+		res.n = int64(n)
+	case "Truncate":
+		res.err = file.Truncate(op.offset)
+	}
+	return res
+}
+
+// clamp limits n to the range [0, max], so that misbehaving implementations do not cause a panic while slicing.
+func clamp(n, max int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// compareFileResults verifies that got diverges in neither count, error nor data from want.
+func compareFileResults(t testing.TB, op string, want, got fileResult) bool {
+	if !holds(t, DifferentialResult, sameError(want.err, got.err), "%s: expected error %v, got %v", op, want.err, got.err) {
+		return false
+	}
+	if want.err != nil {
+		// The oracle's count for a failed Seek is unspecified.
+		return true
+	}
+	return holds(t, DifferentialResult, want.n == got.n, "%s: count differs, expected %d, got %d", op, want.n, got.n) &&
+		holds(t, DifferentialResult, bytes.Equal(want.data, got.data), "%s: data differs", op)
+}
+
+// sameError reports whether both or neither errors are nil, and whether they match the same sentinel errors.
+func sameError(want, got error) bool {
+	if (want == nil) != (got == nil) {
+		return false
+	}
+	for _, sentinel := range sentinels {
+		if errors.Is(want, sentinel) != errors.Is(got, sentinel) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileContent returns the content of file, using Seek to determine its size. A failing Seek or a short ReadAt is
+// reported as a violation, as the size and content of the file disagree, as is a call which panics or does not return
+// within timeout. fileContent returns false if it reported a violation.
+func fileContent(t testing.TB, file File, timeout time.Duration) ([]byte, bool) {
+	var size int64
+	var err error
+	if !within(t, timeout, "Seek", func() { size, err = file.Seek(0, io.SeekEnd) }, "0, io.SeekEnd") {
+		return nil, false
+	}
+	if err != nil {
+		violated(t, DifferentialResult, "Seek(0, io.SeekEnd) failed", "%v", err)
+		return nil, false
+	}
+	var buf = make([]byte, size)
+	var n int
+	if !within(t, timeout, "ReadAt", func() { n, err = file.ReadAt(buf, 0) }, "len(p)=%d, off=0", len(buf)) {
+		return nil, false
+	}
+	if n < len(buf) {
+		violated(t, DifferentialResult, "ReadAt returned fewer bytes than the size reported by Seek",
+			"read %d of %d bytes: %v", n, size, err)
+		return buf[:clamp(n, len(buf))], false
+	}
+	return buf, true
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/reference"
+)
+
+func TestDifferentialFile(t *testing.T) {
+	assert.True(t, iosemantic.DifferentialFile(t, func() iosemantic.File {
+		return reference.New("differential")
+	}, iosemantic.DifferentialOpts{}))
+}
+
+func TestDifferentialFileOpts(t *testing.T) {
+	assert.True(t, iosemantic.DifferentialFile(t, func() iosemantic.File {
+		return reference.New("differential")
+	}, iosemantic.DifferentialOpts{Seed: 42, Operations: 5000, BufferSize: 17, MaxOffset: 1024}))
+}
+
+func TestDifferentialFileRandomSeed(t *testing.T) {
+	assert.True(t, iosemantic.DifferentialFile(t, func() iosemantic.File {
+		return reference.New("differential")
+	}, iosemantic.DifferentialOpts{RandomSeed: true}))
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fuzzSeeds are added to the corpus of every fuzz target, so that go test exercises a few sequences without -fuzz.
+var fuzzSeeds = [][]byte{
+	{},
+	{0x00, 0x00},
+	{0x00, 0x01, 0x00, 0x07, 0x10, 0x00},
+	{0x10, 0x00, 0x10, 0x01, 0x00, 0x00, 0x0f, 0xff},
+	{0x00, 0x10, 0x00, 0x00, 0x00, 0x20, 0x00, 0x10, 0x00, 0x05, 0x00, 0x01},
+}
+
+// FuzzReader registers a fuzz target which decodes the fuzzer provided bytes into a sequence of buffer sizes, and
+// verifies the properties of ImplementsReader for every Read made with those buffers. factory is called once per
+// input and must return a fresh reader over the same content.
+//
+// Call FuzzReader from a fuzz test, and run it with go test -fuzz. Failing inputs are persisted under testdata/fuzz.
+func FuzzReader(f *testing.F, factory func() io.Reader) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var reader = factory()
+		var ops = fuzzData(data)
+		var err error
+		var empty int
+		var shorts shortReads
+
+		for err == nil {
+			size, ok := ops.next(defaultReaderOpts.BufferSize)
+			if !ok {
+				size = defaultReaderOpts.BufferSize
+			}
+
+			var buf = guarded(size)
+			var n int
+			if !within(t, defaultReaderOpts.Timeout, "Read", func() { n, err = reader.Read(buf) }, "len(p)=%d", len(buf)) {
+				return
+			}
+			if !(checkRead(t, "Read", buf, n) && checkGuard(t, "Read", buf) && shorts.check(t, buf, n, err)) {
+				return
+			}
+			// A call with an empty buffer returns (0, nil) without making progress, which io.Reader allows.
+			if len(buf) > 0 && !progressed(&empty, 0, n, err) {
+				violated(t, ReaderProgress, "Read made no progress",
+					"%d consecutive calls to Read returned (0, nil): %v", empty, io.ErrNoProgress)
+				return
+			}
+		}
+		checkEOF(t, "Read", err)
+	})
+}
+
+// FuzzWriterAt registers a fuzz target which decodes the fuzzer provided bytes into a sequence of offsets and buffer
+// sizes, and verifies the properties of ImplementsWriterAt for every WriteAt made with those. The remaining bytes
+// are used as offsets for parallel, non overlapping writes within the range written to so far. factory is called
+// once per input and must return a fresh writer.
+//
+// Call FuzzWriterAt from a fuzz test, and run it with go test -fuzz. Failing inputs are persisted under testdata/fuzz.
+func FuzzWriterAt(f *testing.F, factory func() io.WriterAt) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var writer = factory()
+		var ops = fuzzData(data)
+		var written int64
+
+		for len(ops) >= 4 {
+			off, _ := ops.next(1 << 16)
+			size, _ := ops.next(defaultWriterAtOpts.BufferSize)
+
+			var buf = guarded(size)
+			var n int
+			var err error
+			if !within(t, defaultWriterAtOpts.Timeout, "WriteAt", func() { n, err = writer.WriteAt(buf, int64(off)) },
+				"len(p)=%d, off=%d", len(buf), off) {
+				return
+			}
+			if !(checkWrite(t, "WriteAt", buf, n, err) && checkGuard(t, "WriteAt", buf)) {
+				return
+			}
+
+			if end := int64(off + n); n > 0 && end > written {
+				written = end
+			}
+		}
+
+		if written == 0 {
+			return
+		}
+
+		var seen = make(map[int64]bool)
+		grp, _ := errgroup.WithContext(context.Background())
+		for i := 0; i < 50; i++ {
+			off, ok := ops.next(1 << 16)
+			if !ok {
+				break
+			}
+
+			var at = int64(off) % written
+			if seen[at] {
+				continue
+			}
+			seen[at] = true
+
+			grp.Go(func() error {
+				var buf = make([]byte, 1)
+				var err error
+				if !within(t, defaultWriterAtOpts.Timeout, "WriteAt", func() { _, err = writer.WriteAt(buf, at) }, "len(p)=1, off=%d", at) {
+					return errFailed
+				}
+				if !noError(t, WriterAtParallel, err, "parallel WriteAt(len(p)=1, off=%d) failed", at) {
+					return errFailed
+				}
+				return nil
+			})
+		}
+		_ = grp.Wait()
+	})
+}
+
+// fuzzData decodes fuzzer provided bytes into integers.
+type fuzzData []byte
+
+// next consumes two bytes and returns them as an integer in the range [0, max]. If fewer than two bytes remain,
+// next returns false.
+func (d *fuzzData) next(max int) (int, bool) {
+	if len(*d) < 2 {
+		return 0, false
+	}
+	var v = int(binary.BigEndian.Uint16(*d))
+	*d = (*d)[2:]
+	return v % (max + 1), true
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/djherbis/buffer"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func FuzzReader(f *testing.F) {
+	var content = make([]byte, 4096*10+7)
+	// Zero bytes decode to empty buffers, for which Read returns (0, nil) without making progress.
+	f.Add(make([]byte, 400))
+	iosemantic.FuzzReader(f, func() io.Reader {
+		return bytes.NewReader(content)
+	})
+}
+
+func FuzzWriterAt(f *testing.F) {
+	iosemantic.FuzzWriterAt(f, func() io.WriterAt {
+		return buffer.New(1 << 17)
+	})
+}
@@ -1,9 +1,15 @@
 module github.com/kaiserkarel/iosemantic
 
-go 1.13
+go 1.18
 
 require (
 	github.com/djherbis/buffer v1.1.0
 	github.com/stretchr/testify v1.4.0
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
@@ -0,0 +1,49 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"testing"
+)
+
+const (
+	// guardSize is the number of guard bytes in the spare capacity of buffers passed to implementations.
+	guardSize = 64
+	// guardByte is the value of every guard byte.
+	guardByte = 0x5a
+)
+
+// guarded returns a buffer of length size, whose spare capacity holds guard bytes. An implementation which does
+// p = p[:cap(p)] or append(p, ...) overwrites them.
+func guarded(size int) []byte {
+	var buf = make([]byte, size+guardSize)
+	for i := size; i < len(buf); i++ {
+		buf[i] = guardByte
+	}
+	return buf[:size]
+}
+
+// checkGuard verifies that the spare capacity of p, as returned by guarded, still holds the guard bytes.
+func checkGuard(t testing.TB, op string, p []byte) bool {
+	checking(t, clauseOf(op, "capacity"))
+	for i, b := range p[len(p):cap(p)] {
+		if b != guardByte {
+			return violated(t, clauseOf(op, "capacity"), op+" wrote beyond len(p)",
+				"the byte at offset %d beyond len(p) = %d was modified, corrupting the caller's backing array", i, len(p))
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// defaultTimeout is the deadline of a single call made by the Implements checks using their default options.
+const defaultTimeout = 10 * time.Second
+
+// errFailed is returned from errgroup goroutines once they reported a failure.
+var errFailed = errors.New("iosemantic: check failed")
+
+// errViolation is returned by the helpers of the checks if a call into the implementation violated the properties
+// of its interface, or panicked or did not return. The violation is reported already.
+var errViolation = errors.New("iosemantic: violation")
+
+// timeoutOf returns the deadline of a single call for the Timeout option of a check whose unset fields are set to
+// defaults: zero uses defaultTimeout, and a negative timeout disables the deadline.
+func timeoutOf(timeout time.Duration) time.Duration {
+	switch {
+	case timeout == 0:
+		return defaultTimeout
+	case timeout < 0:
+		return 0
+	}
+	return timeout
+}
+
+// within calls fn, which calls op of the implementation with the arguments described by format and args. It fails if
+// fn does not return within timeout, or if it panics. The arguments are only formatted once the call failed. A timeout
+// of zero disables the deadline.
+//
+// A blocked call cannot be interrupted, so fn keeps running in the background once the deadline expires. The caller
+// must not use the results of fn if within returns false, which it does even if the violation is waived.
+func within(t testing.TB, timeout time.Duration, op string, fn func(), format string, args ...interface{}) bool {
+	checking(t, CallNoPanic)
+	if timeout > 0 {
+		checking(t, CallReturns)
+	}
+	switch v := returns(timeout, op, fn, format, args...); {
+	case v == nil:
+		return true
+	case v.Clause == CallReturns:
+		return failed(t, CallReturns, v.Message, "goroutines:\n%s", v.Stack)
+	default:
+		return failed(t, CallNoPanic, v.Message, "%s", v.Stack)
+	}
+}
+
+// returns calls fn like within, and describes the call if it panicked or did not return within timeout, or returns
+// nil. The stack of a call which did not return holds all goroutines.
+func returns(timeout time.Duration, op string, fn func(), format string, args ...interface{}) *Violation {
+	var panicked *Violation
+	var call = func() { panicked = recovered(op, fn, format, args...) }
+
+	if timeout <= 0 {
+		call()
+		return panicked
+	}
+
+	var done = make(chan struct{})
+	var start = time.Now()
+	go func() {
+		defer close(done)
+		call()
+	}()
+
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return panicked
+	case <-timer.C:
+		message := fmt.Sprintf("%s(%s) did not return after %s", op, fmt.Sprintf(format, args...),
+			time.Since(start).Round(time.Millisecond))
+		return &Violation{Op: op, Clause: CallReturns, Message: message, Stack: goroutines()}
+	}
+}
+
+// failed reports a violation of the clause, and returns false even if the clause is not enforced.
+func failed(t testing.TB, c Clause, failure string, msgAndArgs ...interface{}) bool {
+	violated(t, c, failure, msgAndArgs...)
+	return false
+}
+
+// goroutines returns the stacks of all goroutines.
+func goroutines() []byte {
+	var buf = make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
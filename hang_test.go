@@ -0,0 +1,149 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
+	"github.com/kaiserkarel/iosemantic/reference"
+)
+
+func TestChecksFailForHangingImplementations(t *testing.T) {
+	var cases = []struct {
+		name  string
+		call  string
+		check func(t testing.TB) bool
+	}{
+		{"ImplementsReaderAtOpts/BlockingReaderAt", "ReadAt(len(p)=4096, off=0) did not return", func(t testing.TB) bool {
+			var reader = adversary.NewBlockingReaderAt()
+			t.Cleanup(func() { _ = reader.Close() })
+			return iosemantic.ImplementsReaderAtOpts(t, reader, 0,
+				iosemantic.ReaderAtOpts{BufferSize: 4096, Timeout: 50 * time.Millisecond})
+		}},
+		{"ImplementsWriterOpts/PipeWriter", "Write(len(p)=4096) did not return", func(t testing.TB) bool {
+			var reader, writer = io.Pipe()
+			t.Cleanup(func() { _ = reader.Close() })
+			return iosemantic.ImplementsWriterOpts(t, writer,
+				iosemantic.WriterOpts{BufferSize: 4096, Timeout: 50 * time.Millisecond})
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var rec = &recorder{TB: t}
+			assert.False(t, c.check(rec), "check passed")
+			if assert.Len(t, rec.messages, 1) {
+				assert.Contains(t, rec.messages[0], c.call)
+				assert.True(t, strings.Contains(rec.messages[0], "goroutine "), "missing goroutine dump")
+			}
+		})
+	}
+}
+
+// blockingReader blocks in Read until release is closed.
+type blockingReader struct {
+	release chan struct{}
+}
+
+func (b blockingReader) Read([]byte) (int, error) {
+	<-b.release
+	return 0, io.EOF
+}
+
+// truncateBlockingFile blocks in Truncate until release is closed.
+type truncateBlockingFile struct {
+	*reference.File
+	release chan struct{}
+}
+
+func (f truncateBlockingFile) Truncate(int64) error {
+	<-f.release
+	return nil
+}
+
+func TestHarnessesContinueAfterHangs(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	var cases = []struct {
+		name  string
+		calls int
+		check func(t testing.TB, release chan struct{}) bool
+	}{
+		{"ImplementsReaderWrapper", 3, func(t testing.TB, release chan struct{}) bool {
+			// Only the source returning a single byte per call hangs: once drained, and in ImplementsReaderOpts both
+			// for an empty buffer and once reading.
+			return iosemantic.ImplementsReaderWrapper(t, func(r io.Reader) io.Reader {
+				if fmt.Sprintf("%T", r) == fmt.Sprintf("%T", iotest.OneByteReader(nil)) {
+					return blockingReader{release}
+				}
+				return io.MultiReader(r)
+			}, iosemantic.ReaderWrapperOpts{Timeout: timeout})
+		}},
+		{"ImplementsSplitInvarianceOpts", 10, func(t testing.TB, release chan struct{}) bool {
+			// Every randomly split read hangs, while the writer is still verified.
+			var calls int
+			return iosemantic.ImplementsSplitInvarianceOpts(t, iosemantic.SplitFactory{
+				NewReader: func() io.Reader {
+					calls++
+					if calls > 1 {
+						return blockingReader{release}
+					}
+					return bytes.NewReader(make([]byte, 4096))
+				},
+				NewWriter: func(dst io.Writer) io.Writer { return dst },
+			}, iosemantic.SplitOpts{Seed: 1, Timeout: timeout})
+		}},
+		{"ConsumesWriterOpts", 3, func(t testing.TB, release chan struct{}) bool {
+			// fn blocks once a write failed, which happens for the writer failing on the first call, the partial
+			// writer and the writer accepting a single byte per call.
+			return iosemantic.ConsumesWriterOpts(t, func(w io.Writer) error {
+				if _, err := w.Write(make([]byte, 4096)); err != nil {
+					<-release
+				}
+				return nil
+			}, iosemantic.ConsumerOpts{Timeout: timeout})
+		}},
+		{"DifferentialFile", 1, func(t testing.TB, release chan struct{}) bool {
+			return iosemantic.DifferentialFile(t, func() iosemantic.File {
+				return truncateBlockingFile{File: reference.New("differential"), release: release}
+			}, iosemantic.DifferentialOpts{Timeout: timeout})
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var release = make(chan struct{})
+			t.Cleanup(func() { close(release) })
+
+			var rec = &recorder{TB: t}
+			assert.False(t, c.check(rec, release), "check passed")
+			if assert.Len(t, rec.messages, c.calls) {
+				for _, message := range rec.messages {
+					assert.Contains(t, message, "[call.returns]")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// checkEOF verifies that err, returned by op at the end of the input, is io.EOF itself. Callers compare the end of a
+// stream with ==, so a wrapped io.EOF, such as one returned by fmt.Errorf("...: %w", io.EOF), is not recognized as the
+// end of the stream.
+func checkEOF(t testing.TB, op string, err error) bool {
+	checking(t, clauseOf(op, "eof"))
+	switch {
+	case err == io.EOF:
+		return true
+	case errors.Is(err, io.EOF):
+		return violated(t, clauseOf(op, "eof"), op+" returned a wrapped io.EOF",
+			"%s must return io.EOF itself, as callers compare it using ==, got %q", op, err)
+	default:
+		return violated(t, clauseOf(op, "eof"), op+" did not return io.EOF", "expected io.EOF, got %v", err)
+	}
+}
+
+// checkIs verifies the clause that errors.Is matches err with target. If it does not, but err mentions target, the
+// implementation most likely formatted target with %v instead of wrapping it with %w, which is pointed out.
+func checkIs(t testing.TB, c Clause, err, target error, format string, args ...interface{}) bool {
+	checking(t, c)
+	if errors.Is(err, target) {
+		return true
+	}
+	var message = fmt.Sprintf(format, args...)
+	if err != nil && strings.Contains(err.Error(), target.Error()) {
+		message += fmt.Sprintf("; the error mentions %q, but does not wrap it using %%w", target)
+	}
+	return violated(t, c, message)
+}
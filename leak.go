@@ -0,0 +1,143 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+var defaultLeakOpts = LeakOpts{
+	Grace:   time.Second,
+	Timeout: defaultTimeout,
+}
+
+// DetectLeaks verifies that suite does not leak goroutines or file descriptors. It snapshots the running goroutines
+// and, on Linux, the open file descriptors in /proc/self/fd, then runs suite and closes the implementation it returns.
+// Every goroutine and file descriptor which exists afterwards, but did not exist before, is reported as a leak. Leaked
+// goroutines are reported with their stack, which includes the stack which created them. If Close fails, only the
+// failure is reported.
+//
+// File abstractions often start background goroutines, such as flushers, which must exit once the file is closed.
+// None of the Implements functions notice if they do not.
+//
+// Goroutines and file descriptors of the whole process are compared, so DetectLeaks must not run in parallel with
+// other tests. Use DetectLeaksOpts for more control over the test suite.
+func DetectLeaks(t testing.TB, suite func() io.Closer) bool {
+	return DetectLeaksOpts(t, suite, defaultLeakOpts)
+}
+
+// LeakOpts defines fine tunes controls for the DetectLeaksOpts test.
+type LeakOpts struct {
+	// Grace is how long goroutines and file descriptors are given to disappear after Close returns.
+	Grace time.Duration
+	// Timeout is the deadline of the call to Close. Zero disables the deadline.
+	Timeout time.Duration
+}
+
+// DetectLeaksOpts uses providing options to perform DetectLeaks.
+func DetectLeaksOpts(t testing.TB, suite func() io.Closer, opts LeakOpts) (ok bool) {
+	defer catch(t, "DetectLeaks", &ok)
+
+	var goroutinesBefore = goroutineSet()
+	var fdsBefore = fdSet()
+
+	var closer = suite()
+	var err error
+	// A Close which failed or hung most likely leaks, so the leaks are not reported on top of it.
+	if !(within(t, opts.Timeout, "Close", func() { err = closer.Close() }, "") && noError(t, LeakClose, err, "Close failed")) {
+		return false
+	}
+
+	return leaked(t, goroutinesBefore, fdsBefore, opts.Grace)
+}
+
+// leaked reports every goroutine and file descriptor which is not in the snapshots taken before, once grace expired
+// or all of them disappeared.
+func leaked(t testing.TB, goroutinesBefore, fdsBefore map[string]string, grace time.Duration) bool {
+	checking(t, LeakGoroutine, LeakFD)
+	var leakedGoroutines, leakedFDs []string
+	var deadline = time.Now().Add(grace)
+	for {
+		leakedGoroutines = added(goroutinesBefore, goroutineSet())
+		leakedFDs = added(fdsBefore, fdSet())
+		if len(leakedGoroutines)+len(leakedFDs) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var ok = true
+	for _, leak := range leakedGoroutines {
+		ok = violated(t, LeakGoroutine, "goroutine leaked", "%s", leak) && ok
+	}
+	for _, leak := range leakedFDs {
+		ok = violated(t, LeakFD, "file descriptor leaked", "%s", leak) && ok
+	}
+	return ok
+}
+
+// goroutineSet returns the stack of every running goroutine, keyed by its header, such as "goroutine 7".
+func goroutineSet() map[string]string {
+	var set = make(map[string]string)
+	for _, stack := range bytes.Split(goroutines(), []byte("\n\n")) {
+		// The header is "goroutine 7 [chan receive]:", of which the state changes over time.
+		header := stack
+		if i := bytes.IndexByte(stack, '['); i > 0 {
+			header = bytes.TrimSpace(stack[:i])
+		}
+		set[string(header)] = string(stack)
+	}
+	return set
+}
+
+// fdSet describes every open file descriptor, keyed by its number and target, so that a reused number counts as a new
+// descriptor. It returns an empty set on systems without /proc/self/fd.
+func fdSet() map[string]string {
+	var set = make(map[string]string)
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return set
+	}
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", entry.Name()))
+		if err != nil {
+			// The descriptor used to read the directory is closed by now.
+			continue
+		}
+		var description = fmt.Sprintf("fd %s: %s", entry.Name(), target)
+		set[description] = description
+	}
+	return set
+}
+
+// added returns the value of every entry in after whose key is not in before, sorted.
+func added(before, after map[string]string) []string {
+	var leaks []string
+	for key, value := range after {
+		if _, ok := before[key]; !ok {
+			leaks = append(leaks, value)
+		}
+	}
+	sort.Strings(leaks)
+	return leaks
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/broken"
+	"github.com/kaiserkarel/iosemantic/reference"
+)
+
+func TestDetectLeaks(t *testing.T) {
+	assert.True(t, iosemantic.DetectLeaks(t, func() io.Closer {
+		var file = reference.New("leaks")
+		iosemantic.ImplementsWriter(t, file)
+		iosemantic.ImplementsReaderAt(t, file, 4096*100)
+		return file
+	}))
+}
+
+func TestDetectLeaksOpts(t *testing.T) {
+	assert.True(t, iosemantic.DetectLeaksOpts(t, func() io.Closer {
+		var reader, writer = io.Pipe()
+		go func() {
+			_, _ = writer.Write(make([]byte, 4096))
+			_ = writer.Close()
+		}()
+		iosemantic.ImplementsReader(t, reader)
+		return reader
+	}, iosemantic.LeakOpts{Grace: time.Second}))
+}
+
+func TestDetectLeaksFailsForLeakingImplementations(t *testing.T) {
+	var opts = iosemantic.LeakOpts{Grace: 50 * time.Millisecond}
+
+	t.Run("goroutine", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.DetectLeaksOpts(rec, func() io.Closer {
+			var reader = broken.LeakingReader(bytes.NewReader(make([]byte, 4096)))
+			iosemantic.ImplementsReader(rec, reader)
+			return reader
+		}, opts))
+		if assert.Len(t, rec.messages, 1) {
+			assert.Contains(t, rec.messages[0], "goroutine leaked")
+			assert.Contains(t, rec.messages[0], "broken.LeakingReader", "missing the creation stack")
+		}
+	})
+
+	t.Run("file descriptor", func(t *testing.T) {
+		var file *os.File
+		t.Cleanup(func() { _ = file.Close() })
+
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.DetectLeaksOpts(rec, func() io.Closer {
+			var err error
+			file, err = os.Create(filepath.Join(t.TempDir(), "leak"))
+			assert.NoError(t, err)
+			return io.NopCloser(file)
+		}, opts))
+		if assert.Len(t, rec.messages, 1) {
+			assert.Contains(t, rec.messages[0], "file descriptor leaked")
+			assert.Contains(t, rec.messages[0], file.Name())
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.DetectLeaksOpts(rec, func() io.Closer {
+			return failingCloser{}
+		}, opts))
+		if assert.Len(t, rec.messages, 1) {
+			assert.Contains(t, rec.messages[0], "[leak.close] Close failed")
+		}
+	})
+}
+
+// failingCloser fails to close.
+type failingCloser struct{}
+
+func (failingCloser) Close() error {
+	return errors.New("close failed")
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// observe compares p against snapshot from a separate goroutine, until the returned function is called. That function
+// reports whether p was seen to differ from snapshot in the meantime. If enabled is false, observe does nothing.
+//
+// The observer only catches modifications which last long enough to be seen, and reads p while the implementation
+// may write it; the race detector reports such writes as well.
+func observe(enabled bool, p, snapshot []byte) func() bool {
+	if !enabled {
+		return func() bool { return false }
+	}
+
+	var done = make(chan struct{})
+	var modified bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if !bytes.Equal(p, snapshot) {
+				modified = true
+				return
+			}
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		wg.Wait()
+		return modified
+	}
+}
+
+// notModified verifies that p still equals snapshot after op returned, and that the observer did not see p modified
+// while op ran.
+func notModified(t testing.TB, op string, p, snapshot []byte, observed bool) bool {
+	checking(t, clauseOf(op, "modify"))
+	if !bytes.Equal(p, snapshot) {
+		return violated(t, clauseOf(op, "modify"), op+" modified p", "%s must not modify the slice data, even temporarily", op)
+	}
+	if observed {
+		return violated(t, clauseOf(op, "modify"), op+" temporarily modified p", "%s must not modify the slice data, even temporarily", op)
+	}
+	return true
+}
+
+// writeString verifies the properties of Write for a call to WriteString with the content of p. A string cannot be
+// modified without package unsafe, so only the count and short write properties apply.
+func writeString(t testing.TB, writer io.StringWriter, p []byte, timeout time.Duration) bool {
+	var s = string(p)
+	var n int
+	var err error
+	if !within(t, timeout, "WriteString", func() { n, err = writer.WriteString(s) }, "len(s)=%d", len(s)) {
+		return false
+	}
+	return checkWrite(t, "WriteString", p, n, err)
+}
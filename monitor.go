@@ -0,0 +1,209 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// Violation describes a call which broke the contract of its io interface.
+type Violation struct {
+	// Op is the method which was called, such as "Read" or "WriteAt".
+	Op string
+	// Clause is the ID of the broken clause, such as ReaderCount. It is described by Clauses.
+	Clause Clause
+	// Message describes the broken rule.
+	Message string
+	// Stack is the stack trace of the goroutine which made the call.
+	Stack []byte
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s: %s\n%s", v.Clause, v.Op, v.Message, v.Stack)
+}
+
+// Monitor returns a reader which forwards every call to r unchanged, and calls onViolation for every call to Read
+// which breaks the io.Reader contract. Unlike the Implements functions, Monitor is intended for integration
+// environments and production, where the test suite does not run.
+//
+// The monitors apply the rules of the test suite which a single call reveals. Monitor reports calls returning n < 0 or
+// n > len(p), a wrapped io.EOF instead of io.EOF itself, and (0, nil) returned for a non-empty p 100 times in a row.
+// Conventions which the profiles enforce beyond the documentation, such as a sticky io.EOF, are not monitored.
+//
+// The returned reader only implements io.Reader. Optional interfaces of r, such as io.Closer, io.WriterTo or
+// io.Seeker, are not forwarded, so callers such as io.Copy no longer use them, and r must be closed directly. The
+// other Monitor functions likewise only implement the interface they monitor. A nil onViolation discards violations.
+func Monitor(r io.Reader, onViolation func(Violation)) io.Reader {
+	return &monitoredReader{reader: r, onViolation: onViolation}
+}
+
+// MonitorWriter returns a writer which forwards every call to w unchanged, and calls onViolation for every call to
+// Write which breaks the io.Writer contract: returning n < 0 or n > len(p), or a short write without an error.
+func MonitorWriter(w io.Writer, onViolation func(Violation)) io.Writer {
+	return &monitoredWriter{writer: w, onViolation: onViolation}
+}
+
+// MonitorReaderAt returns a reader which forwards every call to r unchanged, and calls onViolation for every call to
+// ReadAt which breaks the io.ReaderAt contract: returning n < 0 or n > len(p), a short read without an error, or a
+// wrapped io.EOF instead of io.EOF itself.
+func MonitorReaderAt(r io.ReaderAt, onViolation func(Violation)) io.ReaderAt {
+	return &monitoredReaderAt{reader: r, onViolation: onViolation}
+}
+
+// MonitorWriterAt returns a writer which forwards every call to w unchanged, and calls onViolation for every call to
+// WriteAt which breaks the io.WriterAt contract: returning n < 0 or n > len(p), or a short write without an error.
+func MonitorWriterAt(w io.WriterAt, onViolation func(Violation)) io.WriterAt {
+	return &monitoredWriterAt{writer: w, onViolation: onViolation}
+}
+
+// MonitorSeeker returns a seeker which forwards every call to s unchanged, and calls onViolation for every call to
+// Seek which breaks the io.Seeker contract: returning a negative offset without an error, or an offset other than
+// the requested one when seeking relative to the start.
+func MonitorSeeker(s io.Seeker, onViolation func(Violation)) io.Seeker {
+	return &monitoredSeeker{seeker: s, onViolation: onViolation}
+}
+
+type monitoredReader struct {
+	reader      io.Reader
+	onViolation func(Violation)
+	empty       int
+}
+
+func (m *monitoredReader) Read(p []byte) (int, error) {
+	n, err := m.reader.Read(p)
+	if message := readViolation(len(p), n); message != "" {
+		report(m.onViolation, "Read", ReaderCount, message)
+		return n, err
+	}
+	report(m.onViolation, "Read", ReaderEOF, eofViolation(err))
+	// Report the call reaching the limit once, rather than every call after it.
+	if len(p) > 0 && !progressed(&m.empty, defaultEmptyReads, n, err) && m.empty == defaultEmptyReads {
+		report(m.onViolation, "Read", ReaderProgress,
+			fmt.Sprintf("returned (0, nil) %d times in a row: %v", m.empty, io.ErrNoProgress))
+	}
+	return n, err
+}
+
+type monitoredWriter struct {
+	writer      io.Writer
+	onViolation func(Violation)
+}
+
+func (m *monitoredWriter) Write(p []byte) (int, error) {
+	n, err := m.writer.Write(p)
+	reportShort(m.onViolation, "Write", WriterCount, WriterShort, len(p), n, err)
+	return n, err
+}
+
+type monitoredReaderAt struct {
+	reader      io.ReaderAt
+	onViolation func(Violation)
+}
+
+func (m *monitoredReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := m.reader.ReadAt(p, off)
+	reportShort(m.onViolation, "ReadAt", ReaderAtCount, ReaderAtShort, len(p), n, err)
+	report(m.onViolation, "ReadAt", ReaderAtEOF, eofViolation(err))
+	return n, err
+}
+
+type monitoredWriterAt struct {
+	writer      io.WriterAt
+	onViolation func(Violation)
+}
+
+func (m *monitoredWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := m.writer.WriteAt(p, off)
+	reportShort(m.onViolation, "WriteAt", WriterAtCount, WriterAtShort, len(p), n, err)
+	return n, err
+}
+
+type monitoredSeeker struct {
+	seeker      io.Seeker
+	onViolation func(Violation)
+}
+
+func (m *monitoredSeeker) Seek(offset int64, whence int) (int64, error) {
+	abs, err := m.seeker.Seek(offset, whence)
+	c, message := seekViolation(offset, whence, abs, err)
+	report(m.onViolation, "Seek", c, message)
+	return abs, err
+}
+
+// report calls onViolation if it is not nil and message is not empty, with the broken clause c.
+func report(onViolation func(Violation), op string, c Clause, message string) {
+	if onViolation == nil || message == "" {
+		return
+	}
+	onViolation(Violation{Op: op, Clause: c, Message: message, Stack: debug.Stack()})
+}
+
+// reportShort reports a call to op, which is Write, ReadAt or WriteAt, returning n and err for a buffer of length
+// size, if it broke either the count or the short clause of its interface.
+func reportShort(onViolation func(Violation), op string, count, short Clause, size, n int, err error) {
+	if message := readViolation(size, n); message != "" {
+		report(onViolation, op, count, message)
+		return
+	}
+	report(onViolation, op, short, shortViolation(size, n, err))
+}
+
+// readViolation describes the rule broken by a call to Read returning n for a buffer of length size, or returns an
+// empty string.
+func readViolation(size, n int) string {
+	switch {
+	case n < 0:
+		return fmt.Sprintf("returned a negative count n = %d", n)
+	case n > size:
+		return fmt.Sprintf("returned n = %d > len(p) = %d", n, size)
+	}
+	return ""
+}
+
+// eofViolation describes an error wrapping io.EOF instead of being io.EOF itself, or returns an empty string.
+func eofViolation(err error) string {
+	if err != io.EOF && errors.Is(err, io.EOF) {
+		return fmt.Sprintf("returned a wrapped io.EOF %q, but callers compare it using ==", err)
+	}
+	return ""
+}
+
+// shortViolation describes a call to Write, ReadAt or WriteAt returning n < size without an error, or returns an
+// empty string. Unlike Read, these must return an error if n < len(p).
+func shortViolation(size, n int, err error) string {
+	if n < size && err == nil {
+		return fmt.Sprintf("returned n = %d < len(p) = %d without an error", n, size)
+	}
+	return ""
+}
+
+// seekViolation returns the clause broken by a call to Seek returning abs and err, and describes it, or returns empty
+// strings.
+func seekViolation(offset int64, whence int, abs int64, err error) (Clause, string) {
+	if err != nil {
+		return "", ""
+	}
+	switch {
+	case abs < 0:
+		return SeekerNegative, fmt.Sprintf("returned a negative offset %d without an error", abs)
+	case whence == io.SeekStart && abs != offset:
+		return SeekerStart, fmt.Sprintf("returned offset %d after seeking to %d relative to the start", abs, offset)
+	}
+	return "", ""
+}
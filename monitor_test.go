@@ -0,0 +1,109 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/broken"
+)
+
+func TestMonitor(t *testing.T) {
+	var violations []iosemantic.Violation
+	var onViolation = func(v iosemantic.Violation) { violations = append(violations, v) }
+
+	_, err := io.ReadAll(iosemantic.Monitor(bytes.NewReader(make([]byte, 4096)), onViolation))
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	var buf = make([]byte, 10)
+	n, err := iosemantic.Monitor(broken.OverReader(bytes.NewReader(make([]byte, 4096))), onViolation).Read(buf)
+	assert.Equal(t, 11, n)
+	assert.NoError(t, err)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "Read", violations[0].Op)
+		assert.Equal(t, iosemantic.ReaderCount, violations[0].Clause)
+		assert.Contains(t, string(violations[0].Stack), "TestMonitor")
+	}
+}
+
+func TestMonitorWriter(t *testing.T) {
+	var violations []iosemantic.Violation
+	var onViolation = func(v iosemantic.Violation) { violations = append(violations, v) }
+
+	_, err := iosemantic.MonitorWriter(io.Discard, onViolation).Write(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	n, err := iosemantic.MonitorWriter(broken.ShortWriter(io.Discard), onViolation).Write(make([]byte, 10))
+	assert.Equal(t, 5, n)
+	assert.NoError(t, err)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "Write", violations[0].Op)
+		assert.Equal(t, iosemantic.WriterShort, violations[0].Clause)
+	}
+}
+
+func TestMonitorReaderAtAndSeeker(t *testing.T) {
+	var violations []iosemantic.Violation
+	var onViolation = func(v iosemantic.Violation) { violations = append(violations, v) }
+
+	var reader = bytes.NewReader(make([]byte, 10))
+	_, err := iosemantic.MonitorReaderAt(reader, onViolation).ReadAt(make([]byte, 20), 0)
+	assert.Equal(t, io.EOF, err)
+	_, err = iosemantic.MonitorSeeker(reader, onViolation).Seek(-1, io.SeekStart)
+	assert.Error(t, err)
+	assert.Empty(t, violations)
+
+	_, err = iosemantic.MonitorReaderAt(broken.ShortReaderAt(reader), onViolation).ReadAt(make([]byte, 10), 0)
+	assert.NoError(t, err)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "ReadAt", violations[0].Op)
+		assert.Equal(t, iosemantic.ReaderAtShort, violations[0].Clause)
+	}
+}
+
+func TestMonitorNilOnViolation(t *testing.T) {
+	var buf = make([]byte, 10)
+	n, err := iosemantic.Monitor(broken.OverReader(bytes.NewReader(make([]byte, 4096))), nil).Read(buf)
+	assert.NoError(t, err)
+	assert.Greater(t, n, len(buf))
+}
+
+func TestMonitorEOFAndProgress(t *testing.T) {
+	var violations []iosemantic.Violation
+	var onViolation = func(v iosemantic.Violation) { violations = append(violations, v) }
+
+	_, err := io.ReadAll(iosemantic.Monitor(broken.WrappedEOFReader(bytes.NewReader(make([]byte, 4096))), onViolation))
+	assert.Error(t, err)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, iosemantic.ReaderEOF, violations[0].Clause)
+	}
+
+	violations = nil
+	var reader = iosemantic.Monitor(broken.NoProgressReader(), onViolation)
+	for i := 0; i < 200; i++ {
+		_, _ = reader.Read(make([]byte, 10))
+	}
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, iosemantic.ReaderProgress, violations[0].Clause)
+	}
+}
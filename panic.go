@@ -0,0 +1,47 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"fmt"
+	"runtime/debug"
+	"testing"
+)
+
+// recovered calls fn, which calls op of the implementation with the arguments described by format and args. If fn
+// panics, recovered returns the panic as a violation, else it returns nil.
+func recovered(op string, fn func(), format string, args ...interface{}) (v *Violation) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = &Violation{
+				Op:      op,
+				Clause:  CallNoPanic,
+				Message: fmt.Sprintf("%s(%s) panicked: %v", op, fmt.Sprintf(format, args...), r),
+				Stack:   debug.Stack(),
+			}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// catch reports a panic raised while running the check op as a violation, and sets ok to false. It must be deferred
+// by checks which call into the implementation in too many places to guard each call with within.
+func catch(t testing.TB, op string, ok *bool) {
+	if r := recover(); r != nil {
+		*ok = failed(t, CallNoPanic, fmt.Sprintf("%s panicked: %v", op, r), "%s", debug.Stack())
+	}
+}
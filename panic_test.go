@@ -0,0 +1,111 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/broken"
+)
+
+func TestChecksReportPanics(t *testing.T) {
+	var rec = &recorder{TB: t}
+	var reader = broken.PanickingReaderAt(bytes.NewReader(make([]byte, 4096*100)))
+	assert.False(t, iosemantic.ImplementsReaderAt(rec, reader, 4096*100))
+
+	// Every parallel read at an odd offset panics, and each panic is reported.
+	if assert.Len(t, rec.messages, 25) {
+		var found bool
+		for _, message := range rec.messages {
+			found = found || strings.Contains(message, "ReadAt(len(p)=4096, off=1) panicked: broken: unaligned offset")
+		}
+		assert.True(t, found, "missing the panic of the read at offset 1")
+		assert.Contains(t, rec.messages[0], "panickingReaderAt", "missing the stack of the panic")
+	}
+}
+
+func TestChecksReportPanicsInWrappers(t *testing.T) {
+	var rec = &recorder{TB: t}
+	iosemantic.UseProfile(rec, iosemantic.Conventional)
+	assert.False(t, iosemantic.ImplementsReaderWrapper(rec, func(r io.Reader) io.Reader {
+		if _, ok := r.(*io.PipeReader); ok {
+			panic("wrapping a pipe")
+		}
+		return io.MultiReader(r)
+	}, iosemantic.ReaderWrapperOpts{}))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "[call.no-panic] Read(len(p)=40960) panicked: wrapping a pipe")
+	}
+}
+
+// panicOnSecondRead returns zeros forever, but panics on its second call to Read, and ignores Close.
+type panicOnSecondRead struct {
+	calls int
+}
+
+func (p *panicOnSecondRead) Read(buf []byte) (int, error) {
+	if len(buf) > 0 {
+		p.calls++
+	}
+	if p.calls == 2 {
+		panic("second read")
+	}
+	return len(buf), nil
+}
+
+func (p *panicOnSecondRead) Close() error {
+	return nil
+}
+
+// panickingStringWriter panics in Write, and reports short string writes without an error.
+type panickingStringWriter struct{}
+
+func (panickingStringWriter) Write([]byte) (int, error) {
+	panic("write")
+}
+
+func (panickingStringWriter) WriteString(string) (int, error) {
+	return 0, nil
+}
+
+func TestChecksContinueAfterPanics(t *testing.T) {
+	t.Run("reader", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.ImplementsReaderOpts(rec, &panicOnSecondRead{}, iosemantic.ReaderOpts{Close: true}))
+		if assert.Len(t, rec.messages, 2) {
+			assert.Contains(t, rec.messages[0], "[call.no-panic] Read(len(p)=4096) panicked: second read")
+			assert.Contains(t, rec.messages[1], "[reader.close] Read did not return an error after Close")
+		}
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.ImplementsWriterOpts(rec, panickingStringWriter{}, iosemantic.WriterOpts{
+			BufferSize:  4096,
+			WriteString: true,
+		}))
+		if assert.Len(t, rec.messages, 2) {
+			assert.Contains(t, rec.messages[0], "[call.no-panic] Write(len(p)=4096) panicked: write")
+			assert.Contains(t, rec.messages[1], "[writer.short]")
+		}
+	})
+}
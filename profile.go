@@ -0,0 +1,226 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Profile selects the clauses which are enforced. Violations of clauses which are not enforced are logged instead.
+type Profile int
+
+const (
+	// Standard is used by tests without a profile. It enforces the clauses the checks have always enforced: those of
+	// Minimal, and the conventions which nearly every implementation follows, such as not returning (0, nil) forever
+	// and not writing to the spare capacity of p.
+	Standard Profile = iota
+	// Minimal only enforces what the documentation of the io package requires, apart from not retaining p, which is
+	// verified with the other memory checks of Paranoid.
+	Minimal
+	// Conventional enforces Minimal, and the conventions consumers commonly rely on: sticky io.EOF and write errors,
+	// no (0, nil) results, and returning the data which is available instead of waiting for more.
+	Conventional
+	// Paranoid enforces Conventional, and guards against implementations misusing memory or leaking resources. It
+	// detects retention and modification of p in every check, guards the spare capacity of p, and verifies that no
+	// goroutines or file descriptors outlive the test.
+	Paranoid
+)
+
+func (p Profile) String() string {
+	switch p {
+	case Standard:
+		return "Standard"
+	case Minimal:
+		return "Minimal"
+	case Conventional:
+		return "Conventional"
+	case Paranoid:
+		return "Paranoid"
+	}
+	return fmt.Sprintf("Profile(%d)", int(p))
+}
+
+// enforces reports whether the profile enforces the clause.
+func (p Profile) enforces(c Clause) bool {
+	info, _ := lookup(c)
+	if p == Standard {
+		return info.Standard
+	}
+	return info.Profile != Standard && info.Profile <= p
+}
+
+// Waiver exempts a clause from enforcement for a test, for implementations which deliberately deviate from it.
+type Waiver struct {
+	Clause Clause
+	// Justification explains why the clause does not apply. It is required, and printed with every waived violation.
+	Justification string
+}
+
+// session holds the profile and waivers of a test, and of its subtests, and records the clauses checked for them.
+type session struct {
+	profile Profile
+	waivers map[Clause]string
+
+	mu      sync.Mutex
+	checked map[Clause]bool
+}
+
+var sessions = struct {
+	sync.Mutex
+	byName map[string]*session
+}{byName: make(map[string]*session)}
+
+// UseProfile selects the profile enforced by the checks for t and its subtests, and waives the given clauses. A
+// waiver without a justification, or for an unknown clause, fails the test. The Paranoid profile also verifies that
+// no goroutines or file descriptors created after UseProfile remain once the test and its cleanups are done.
+//
+// Once the test is done, UseProfile logs a coverage summary, listing which clauses were checked, skipped or waived.
+func UseProfile(t testing.TB, profile Profile, waivers ...Waiver) {
+	var s = &session{profile: profile, waivers: make(map[Clause]string), checked: make(map[Clause]bool)}
+	for _, waiver := range waivers {
+		if _, ok := lookup(waiver.Clause); !ok {
+			assert.Fail(t, "waiver of unknown clause "+string(waiver.Clause))
+			continue
+		}
+		if strings.TrimSpace(waiver.Justification) == "" {
+			assert.Fail(t, "waiver of "+string(waiver.Clause)+" without a justification")
+			continue
+		}
+		s.waivers[waiver.Clause] = waiver.Justification
+		t.Logf("[%s] waived: %s", waiver.Clause, waiver.Justification)
+	}
+
+	sessions.Lock()
+	sessions.byName[t.Name()] = s
+	sessions.Unlock()
+
+	// Cleanups run in reverse order, so the leak check of the Paranoid profile still sees the session.
+	t.Cleanup(func() {
+		sessions.Lock()
+		if sessions.byName[t.Name()] == s {
+			delete(sessions.byName, t.Name())
+		}
+		sessions.Unlock()
+		t.Log(s.coverage())
+	})
+
+	if profile == Paranoid {
+		var goroutinesBefore = goroutineSet()
+		var fdsBefore = fdSet()
+		t.Cleanup(func() {
+			leaked(t, goroutinesBefore, fdsBefore, time.Second)
+		})
+	}
+}
+
+// sessionOf returns the session of t, or of the closest parent test with a session. If there is none, t gets a
+// session of the Standard profile, which logs its coverage summary once t is done like UseProfile.
+func sessionOf(t testing.TB) *session {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	for name := t.Name(); ; {
+		if s, ok := sessions.byName[name]; ok {
+			return s
+		}
+		i := strings.LastIndexByte(name, '/')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+
+	var s = &session{profile: Standard, checked: make(map[Clause]bool)}
+	var name = t.Name()
+	sessions.byName[name] = s
+	t.Cleanup(func() {
+		sessions.Lock()
+		// UseProfile may have replaced the session since.
+		if sessions.byName[name] == s {
+			delete(sessions.byName, name)
+		}
+		sessions.Unlock()
+		t.Log(s.coverage())
+	})
+	return s
+}
+
+// enforced reports whether the profile of t enforces the clause.
+func enforced(t testing.TB, c Clause) bool {
+	return sessionOf(t).profile.enforces(c)
+}
+
+// detect reports whether an optional, costly detection of violations runs, because it was requested through an
+// option, or because the Paranoid profile is used.
+func detect(t testing.TB, option bool) bool {
+	return option || sessionOf(t).profile == Paranoid
+}
+
+// violated reports a violation of the clause. If the profile of t enforces the clause and it is not waived, the
+// test fails and violated returns false. Otherwise the violation is logged, and violated returns true so that the
+// check continues.
+func violated(t testing.TB, c Clause, failure string, msgAndArgs ...interface{}) bool {
+	return violation(t, c, enforced(t, c), failure, msgAndArgs...)
+}
+
+// violation reports a violation of the clause like violated, but enforces the clause if enforce is set, regardless
+// of the profile.
+func violation(t testing.TB, c Clause, enforce bool, failure string, msgAndArgs ...interface{}) bool {
+	var s = sessionOf(t)
+	s.check(c)
+	var title = fmt.Sprintf("[%s] %s", c, failure)
+	if justification, ok := s.waivers[c]; ok {
+		t.Logf("%s\n%s\nwaived: %s", title, message(msgAndArgs), justification)
+		return true
+	}
+	if !enforce {
+		t.Logf("%s\n%s\nnot enforced by the %s profile", title, message(msgAndArgs), s.profile)
+		return true
+	}
+	return assert.Fail(t, title, msgAndArgs...)
+}
+
+// holds reports a violation of the clause, described by format and args, unless cond is set.
+func holds(t testing.TB, c Clause, cond bool, format string, args ...interface{}) bool {
+	checking(t, c)
+	return cond || violated(t, c, fmt.Sprintf(format, args...))
+}
+
+// noError reports a violation of the clause, described by format and args, if err is not nil.
+func noError(t testing.TB, c Clause, err error, format string, args ...interface{}) bool {
+	checking(t, c)
+	return err == nil || violated(t, c, fmt.Sprintf(format, args...), "unexpected error: %v", err)
+}
+
+// message formats msgAndArgs the way testify does.
+func message(msgAndArgs []interface{}) string {
+	switch {
+	case len(msgAndArgs) == 0:
+		return ""
+	case len(msgAndArgs) == 1:
+		return fmt.Sprint(msgAndArgs[0])
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return fmt.Sprintf(format, msgAndArgs[1:]...)
+	}
+	return fmt.Sprint(msgAndArgs...)
+}
@@ -0,0 +1,188 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/broken"
+)
+
+func TestUseProfile(t *testing.T) {
+	iosemantic.UseProfile(t, iosemantic.Conventional)
+	assert.True(t, iosemantic.ImplementsReader(t, bytes.NewReader(make([]byte, 4096*10))))
+	assert.True(t, iosemantic.ImplementsReaderWrapper(t, func(r io.Reader) io.Reader {
+		return io.LimitReader(r, 30000)
+	}, iosemantic.ReaderWrapperOpts{}))
+}
+
+func TestUseProfileParanoid(t *testing.T) {
+	iosemantic.UseProfile(t, iosemantic.Paranoid)
+	var buf bytes.Buffer
+	assert.True(t, iosemantic.ImplementsWriterOpts(t, &buf, iosemantic.WriterOpts{BufferSize: 4096, Readback: buf.Bytes}))
+	assert.True(t, iosemantic.ImplementsReader(t, &buf))
+}
+
+// logger records the messages logged by a check.
+type logger struct {
+	testing.TB
+	logs []string
+}
+
+func (l *logger) Log(args ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprint(args...))
+}
+
+func (l *logger) Logf(format string, args ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+}
+
+// recorder records failures reported by a check, instead of failing the test.
+type recorder struct {
+	testing.TB
+	mu       sync.Mutex
+	failed   bool
+	messages []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = true
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Helper() {}
+
+func TestUseProfileCoverage(t *testing.T) {
+	var log *logger
+	t.Run("reader", func(t *testing.T) {
+		log = &logger{TB: t}
+		iosemantic.UseProfile(log, iosemantic.Minimal, iosemantic.Waiver{
+			Clause:        iosemantic.ReaderEOF,
+			Justification: "the reader is only consumed by io.ReadAll",
+		})
+		assert.True(t, iosemantic.ImplementsReader(log, bytes.NewReader(make([]byte, 4096*10))))
+	})
+
+	if assert.NotEmpty(t, log.logs) {
+		var coverage = log.logs[len(log.logs)-1]
+		assert.Contains(t, coverage, "coverage of the Minimal profile")
+		assert.Regexp(t, `checked: reader\.count, call\.returns`, coverage)
+		assert.Regexp(t, `skipped: reader\.short, reader\.empty, reader\.retention, reader\.capacity,`, coverage)
+		assert.Regexp(t, `waived: reader\.eof$`, coverage)
+	}
+}
+
+func TestStandardProfileCoverage(t *testing.T) {
+	var log *logger
+	t.Run("reader", func(t *testing.T) {
+		log = &logger{TB: t}
+		assert.True(t, iosemantic.ImplementsReader(log, bytes.NewReader(make([]byte, 4096*10))))
+	})
+
+	if assert.NotEmpty(t, log.logs) {
+		var coverage = log.logs[len(log.logs)-1]
+		assert.Contains(t, coverage, "coverage of the Standard profile")
+		assert.Regexp(t, `checked: reader\.count, reader\.empty, reader\.eof, reader\.capacity, reader\.progress,`, coverage)
+	}
+}
+
+func TestUseProfileShortReads(t *testing.T) {
+	for _, profile := range []iosemantic.Profile{iosemantic.Minimal, iosemantic.Conventional, iosemantic.Paranoid} {
+		t.Run(profile.String(), func(t *testing.T) {
+			iosemantic.UseProfile(t, profile)
+			reader := iotest.HalfReader(bytes.NewReader(make([]byte, 4096*10)))
+			assert.True(t, iosemantic.ImplementsReader(t, reader))
+		})
+	}
+}
+
+func TestProfilesSelectEnforcedClauses(t *testing.T) {
+	t.Run("minimal", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Minimal)
+		assert.True(t, iosemantic.ImplementsReader(rec, broken.CapacityReader(bytes.NewReader(make([]byte, 4096*10)))))
+		assert.Empty(t, rec.messages)
+	})
+
+	t.Run("waiver", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Standard, iosemantic.Waiver{
+			Clause:        iosemantic.ReaderCapacity,
+			Justification: "the reader owns the backing array",
+		})
+		assert.True(t, iosemantic.ImplementsReader(rec, broken.CapacityReader(bytes.NewReader(make([]byte, 4096*10)))))
+		assert.Empty(t, rec.messages)
+	})
+
+	t.Run("waiver without justification", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Standard, iosemantic.Waiver{Clause: iosemantic.ReaderCapacity})
+		if assert.Len(t, rec.messages, 1) {
+			assert.Contains(t, rec.messages[0], "without a justification")
+		}
+	})
+
+	t.Run("clause in message", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		assert.False(t, iosemantic.ImplementsReader(rec, broken.CapacityReader(bytes.NewReader(make([]byte, 4096*10)))))
+		if assert.NotEmpty(t, rec.messages) {
+			assert.Contains(t, rec.messages[0], "[reader.capacity]")
+		}
+	})
+}
+
+// resumingReader returns io.EOF once, and data afterwards, which io.Reader allows.
+type resumingReader struct {
+	eof bool
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if !r.eof {
+		r.eof = true
+		return 0, io.EOF
+	}
+	return len(p), nil
+}
+
+func TestUseProfileConventional(t *testing.T) {
+	t.Run("sticky EOF", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Conventional)
+		assert.False(t, iosemantic.ImplementsReader(rec, &resumingReader{}))
+		assert.Contains(t, strings.Join(rec.messages, "\n"), "[reader.sticky-eof]")
+	})
+
+	t.Run("available", func(t *testing.T) {
+		var rec = &recorder{TB: t}
+		iosemantic.UseProfile(rec, iosemantic.Conventional)
+		assert.False(t, iosemantic.ImplementsReaderWrapper(rec, broken.FullReader, iosemantic.ReaderWrapperOpts{}))
+		assert.Contains(t, strings.Join(rec.messages, "\n"), "[readerwrapper.available]")
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Property is a custom clause, such as a guarantee of a storage backend which the io interfaces do not make. Once
+// registered using Register, a property is verified by ImplementsAll, and takes part in profiles, waivers and coverage
+// summaries like the built-in clauses.
+type Property struct {
+	// Clause is the ID of the property, such as "file.truncate-zeros". It must not collide with another clause.
+	Clause Clause
+	// Interface is a nil pointer to the interface the property applies to, such as (*io.ReadWriteSeeker)(nil).
+	// ImplementsAll only verifies the property for implementations of the interface.
+	Interface interface{}
+	// Doc is the sentence of the documentation the property comes from. It may be empty.
+	Doc string
+	// Summary describes what Check verifies.
+	Summary string
+	// Profile is the least strict profile enforcing the property, defaulting to Minimal. Tests without a profile
+	// enforce every property.
+	Profile Profile
+	// Check verifies the property using fresh instances returned by factory, which implement Interface. Violations are
+	// reported through report. Check returns false if the property does not hold.
+	Check func(t testing.TB, factory func() interface{}, report Reporter) bool
+}
+
+// Reporter reports a violation of a property, described by failure and msgAndArgs. Like a violation of a built-in
+// clause, it fails the test and returns false, unless the property is waived or not enforced by the profile, in which
+// case the violation is logged and Reporter returns true so that the check may continue.
+type Reporter func(failure string, msgAndArgs ...interface{}) bool
+
+// property is a registered Property, together with the interface type it applies to.
+type property struct {
+	Property
+	iface reflect.Type
+}
+
+// properties are the registered properties, in the order of their registration. They are guarded by catalogueMu.
+var properties []property
+
+// Register adds the property to the catalogue. It panics if the clause is empty or already exists, if Interface is
+// not a nil pointer to an interface, or if Check is nil. Register is meant to be called from an init function or
+// TestMain, before the checks run.
+func Register(p Property) {
+	if p.Clause == "" {
+		panic("iosemantic: property without a clause")
+	}
+	var iface = reflect.TypeOf(p.Interface)
+	if iface == nil || iface.Kind() != reflect.Ptr || iface.Elem().Kind() != reflect.Interface {
+		panic("iosemantic: the interface of " + p.Clause + " is not a nil pointer to an interface")
+	}
+	if p.Check == nil {
+		panic("iosemantic: property " + p.Clause + " without a check")
+	}
+	if p.Profile == Standard {
+		p.Profile = Minimal
+	}
+
+	catalogueMu.Lock()
+	defer catalogueMu.Unlock()
+	if _, ok := clauses[p.Clause]; ok {
+		panic("iosemantic: clause " + p.Clause + " is already registered")
+	}
+	var info = ClauseInfo{
+		ID:        p.Clause,
+		Interface: iface.Elem().String(),
+		Doc:       p.Doc,
+		Summary:   p.Summary,
+		Profile:   p.Profile,
+		Standard:  true,
+	}
+	clauses[p.Clause] = info
+	catalogue = append(catalogue, info)
+	properties = append(properties, property{Property: p, iface: iface.Elem()})
+}
+
+// unregister removes the property registered for the clause.
+func unregister(c Clause) {
+	catalogueMu.Lock()
+	defer catalogueMu.Unlock()
+	delete(clauses, c)
+	for i, info := range catalogue {
+		if info.ID == c {
+			catalogue = append(catalogue[:i:i], catalogue[i+1:]...)
+			break
+		}
+	}
+	for i, p := range properties {
+		if p.Clause == c {
+			properties = append(properties[:i:i], properties[i+1:]...)
+			break
+		}
+	}
+}
+
+// registered returns the registered properties.
+func registered() []property {
+	catalogueMu.RLock()
+	defer catalogueMu.RUnlock()
+	return append([]property(nil), properties...)
+}
+
+// verify runs the check of the property, reporting its violations as violations of its clause.
+func (p property) verify(t testing.TB, factory func() interface{}) bool {
+	checking(t, p.Clause)
+	return p.Check(t, factory, func(failure string, msgAndArgs ...interface{}) bool {
+		return violated(t, p.Clause, failure, msgAndArgs...)
+	})
+}
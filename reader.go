@@ -18,68 +18,230 @@ package iosemantic
 import (
 	"io"
 	"testing"
-
-	"github.com/stretchr/testify/assert"
+	"time"
 )
 
 // DefaultReaderOpts are the options used by ImplementsReader.
 var defaultReaderOpts = ReaderOpts{
 	BufferSize: 4096,
+	Timeout:    defaultTimeout,
 }
 
 // ImplementsReader verifies the following properties for a reader:
 //
 // 1. n <= len(p) (where p is the buffer passed to the Read method).
-// 2. if 0 < n < len(p), an error is returned; or the next call to read
-//    returns 0, io.EOF
-// 3. if len(p) == 0, n == 0
+// 2. if 0 < n < len(p), an error is returned; or the next call to read returns 0 and an error.
+// 3. the reader returns io.EOF once drained, not an error wrapping io.EOF.
+// 4. if len(p) == 0, n == 0
+// 5. Read does not write to the spare capacity of p.
+// 6. Read does not keep returning (0, nil), which callers such as bufio give up on with io.ErrNoProgress.
 //
+// The second property is stricter than io.Reader, which conventionally returns what is available instead of waiting
+// for more, so only tests without a profile enforce it. Set ReaderOpts.ShortReads for readers which do so, such as
+// readers over a network connection or wrapping another reader. Readers which never reach EOF are verified for a byte budget set through ReaderOpts.MaxBytes.
 // Use ImplementsReaderOpts for more control over the test suite.
-func ImplementsReader(t *testing.T, reader io.Reader) bool {
+func ImplementsReader(t testing.TB, reader io.Reader) bool {
 	return ImplementsReaderOpts(t, reader, defaultReaderOpts)
 }
 
 // ReaderOpts defines fine tunes controls for the ImplementsReaderOpts test.
 type ReaderOpts struct {
+	// BufferSize is the length of p passed to Read. Zero uses 4096 bytes, as a read into an empty buffer makes no
+	// progress.
 	BufferSize int
+	// DetectRetention passes a new buffer to every call to Read, and scribbles over it once Read returns. The buffers
+	// must not be modified afterwards, as Read must not retain p. The buffers are kept until the reader is drained.
+	DetectRetention bool
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
+	// EmptyReads is the number of consecutive calls to Read returning (0, nil) after which the reader is considered
+	// to make no progress. Zero uses defaultEmptyReads.
+	EmptyReads int
+	// MaxBytes, if set, stops reading once MaxBytes bytes have been read, for readers which never reach EOF such as
+	// random sources, generators and subscriptions. Reaching EOF earlier is allowed.
+	MaxBytes int64
+	// Close closes the reader once reading stopped, after which Read must return an error within EmptyReads calls. The
+	// reader must implement io.Closer.
+	Close bool
+	// StickyEOF requires that once Read returned io.EOF, every later call returns (0, io.EOF) as well. Many consumers
+	// assume this, but io.Reader does not require it. If unset, and the profile does not enforce the clause, whether it
+	// holds is logged.
+	StickyEOF bool
+	// ShortReads allows a short read without an error to be followed by more data, as Read conventionally returns what
+	// is available instead of waiting for more. If unset, the call following such a short read must return 0 and an
+	// error.
+	ShortReads bool
 }
 
+// defaultEmptyReads is the number of consecutive (0, nil) results after which bufio returns io.ErrNoProgress.
+const defaultEmptyReads = 100
+
 // ImplementsReaderOpts uses providing options to perform ImplementsReader.
-func ImplementsReaderOpts(t *testing.T, reader io.Reader, opts ReaderOpts) bool {
-	var buf = make([]byte, opts.BufferSize)
+func ImplementsReaderOpts(t testing.TB, reader io.Reader, opts ReaderOpts) bool {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultReaderOpts.BufferSize
+	}
+
+	// The remaining properties are verified even if a call failed, panicked or did not return, so that every failure
+	// is reported.
+	var ok = noopRead(t, "Read", reader, opts.Timeout)
+	var scribbled [][]byte
+	ok = readSequential(t, reader, opts, &scribbled) && ok
+	ok = notRetained(t, "Read", scribbled) && ok
+	if opts.Close {
+		ok = readAfterClose(t, reader, opts) && ok
+	}
+	return ok
+}
+
+// readSequential reads the reader until it is drained, verifying every call to Read. If retention is detected, the
+// buffers passed to Read are scribbled over and appended to scribbled once Read returns. A call which panics or does
+// not return ends the reading, skipping the properties of the end of the input.
+func readSequential(t testing.TB, reader io.Reader, opts ReaderOpts, scribbled *[][]byte) bool {
+	var buf = guarded(opts.BufferSize)
 	var err error
-	var n int
 
-	if !noopRead(t, reader) {
+	var retention = detect(t, opts.DetectRetention)
+	var empty int
+	var total int64
+	var shorts = shortReads{disabled: opts.ShortReads}
+	checking(t, ReaderProgress)
+	for err == nil && (opts.MaxBytes <= 0 || total < opts.MaxBytes) {
+		if retention {
+			buf = guarded(opts.BufferSize)
+		}
+
+		var n int
+		if !within(t, opts.Timeout, "Read", func() { n, err = reader.Read(buf) }, "len(p)=%d", len(buf)) {
+			return false
+		}
+		if !(checkRead(t, "Read", buf, n) && checkGuard(t, "Read", buf) && shorts.check(t, buf, n, err)) {
+			return false
+		}
+		if !progressed(&empty, opts.EmptyReads, n, err) {
+			// Further calls would not return either, so the reader is considered drained if the clause is not
+			// enforced.
+			if !violated(t, ReaderProgress, "Read made no progress",
+				"%d consecutive calls to Read returned (0, nil): %v", empty, io.ErrNoProgress) {
+				return false
+			}
+			break
+		}
+		total += int64(n)
+
+		if retention {
+			scribble(buf)
+			*scribbled = append(*scribbled, buf)
+		}
+	}
+	return err == nil || (checkEOF(t, "Read", err) && checkStickyEOF(t, reader, opts.StickyEOF, opts.Timeout))
+}
+
+// readAfterClose closes the reader, and verifies that Read returns an error afterwards. Data buffered before Close may
+// still be returned by the first calls.
+func readAfterClose(t testing.TB, reader io.Reader, opts ReaderOpts) bool {
+	checking(t, ReaderClose)
+	closer, ok := reader.(io.Closer)
+	if !ok {
+		return violated(t, ReaderClose, "Close is set, but the reader does not implement io.Closer", "%T", reader)
+	}
+
+	var err error
+	if !within(t, opts.Timeout, "Close", func() { err = closer.Close() }, "") {
 		return false
 	}
+	if err != nil {
+		return violated(t, ReaderClose, "Close failed", "%v", err)
+	}
 
-	for err == nil {
-		var a int
-		a, err = reader.Read(buf)
-		n += a
-		if !(assert.GreaterOrEqual(t, a, 0) &&
-			assert.LessOrEqual(t, opts.BufferSize, n)) {
-			return false
+	var limit = opts.EmptyReads
+	if limit <= 0 {
+		limit = defaultEmptyReads
+	}
+
+	var buf = guarded(opts.BufferSize)
+	for calls := 0; err == nil; calls++ {
+		if calls == limit {
+			return violated(t, ReaderClose, "Read did not return an error after Close",
+				"%d calls to Read after Close returned no error", calls)
 		}
 
-		if 0 < n && n < opts.BufferSize {
-			return errNext(t, reader)
+		var n int
+		if !within(t, opts.Timeout, "Read", func() { n, err = reader.Read(buf) }, "len(p)=%d", len(buf)) {
+			return false
 		}
+		if !(checkRead(t, "Read", buf, n) && checkGuard(t, "Read", buf)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRead verifies that 0 <= n <= len(p) for a single call to op, which is Read or ReadAt.
+func checkRead(t testing.TB, op string, p []byte, n int) bool {
+	checking(t, clauseOf(op, "count"))
+	if message := readViolation(len(p), n); message != "" {
+		return violated(t, clauseOf(op, "count"), op+" "+message)
+	}
+	return true
+}
+
+// progressed counts consecutive calls to Read returning (0, nil) in empty, and reports whether fewer than limit
+// calls did so.
+func progressed(empty *int, limit, n int, err error) bool {
+	if n != 0 || err != nil {
+		*empty = 0
+		return true
+	}
+	if limit <= 0 {
+		limit = defaultEmptyReads
 	}
-	return assert.EqualError(t, err, io.EOF.Error())
+	*empty++
+	return *empty < limit
 }
 
-// noopRead verifies that a 0 length buffer is not read into.
-func noopRead(t *testing.T, reader io.Reader) bool {
-	var buf = make([]byte, 0)
-	n, err := reader.Read(buf)
-	return assert.NoError(t, err) && assert.Equal(t, n, 0)
+// shortReads tracks short reads without an error, which must be followed by a call returning 0 and an error.
+type shortReads struct {
+	// pending is set if the previous call returned a short read without an error.
+	pending bool
+	// disabled is set if short reads are allowed, or once a violation was reported.
+	disabled bool
 }
 
-// errNext verifies that the next call to read returns 0, err.
-func errNext(t *testing.T, reader io.Reader) bool {
-	var buf = make([]byte, 10)
-	n, err := reader.Read(buf)
-	return assert.Error(t, err) && assert.Equal(t, n, 0)
+// check verifies a call to Read which returned n and err for p, if the previous call returned a short read without
+// an error.
+func (s *shortReads) check(t testing.TB, p []byte, n int, err error) bool {
+	if s.disabled || len(p) == 0 {
+		return true
+	}
+	if s.pending {
+		checking(t, ReaderShort)
+		if n != 0 || err == nil {
+			// Report once, as a reader returning what is available would be reported for every call.
+			s.disabled = true
+			return violated(t, ReaderShort, "Read returned a short read without an error, and the next call did not "+
+				"return 0 and an error", "got (%d, %v); set ReaderOpts.ShortReads if the reader returns what is available", n, err)
+		}
+	}
+	s.pending = 0 < n && n < len(p) && err == nil
+	return true
+}
+
+// noopRead verifies that reading into a 0 length buffer returns (0, nil). op is the method being verified, which
+// reader calls.
+func noopRead(t testing.TB, op string, reader io.Reader, timeout time.Duration) bool {
+	checking(t, clauseOf(op, "empty"))
+	var buf = guarded(0)
+	var n int
+	var err error
+	if !within(t, timeout, op, func() { n, err = reader.Read(buf) }, "len(p)=0") {
+		return false
+	}
+	if n != 0 || err != nil {
+		if !violated(t, clauseOf(op, "empty"), op+" with len(p) == 0 did not return (0, nil)", "got (%d, %v)", n, err) {
+			return false
+		}
+	}
+	return checkGuard(t, op, buf)
 }
@@ -19,14 +19,14 @@ import (
 	"context"
 	"io"
 	"testing"
+	"time"
 
 	"golang.org/x/sync/errgroup"
-
-	"github.com/stretchr/testify/assert"
 )
 
 var defaultReaderAtOpts = ReaderAtOpts{
 	BufferSize: 4096,
+	Timeout:    defaultTimeout,
 }
 
 // ImplementsReaderAt verifies the following properties for a io.ReaderAt:
@@ -35,54 +35,115 @@ var defaultReaderAtOpts = ReaderAtOpts{
 // 2. if 0 < n < len(p), an error is returned;
 // 3. if len(p) == 0, n == 0
 // 4. Parallel ReadAt calls do not result in errors.
+// 5. if the reader implements io.Seeker, ReadAt does not affect the seek offset.
+// 6. ReadAt does not write to the spare capacity of p.
 //
 // ImplementsReaderAt is a more strict version of ImplementsReader, just like the semantics of io.Reader and io.ReaderAt.
 // Use ImplementsReaderAtOpts for more control over the test suite.
-func ImplementsReaderAt(t *testing.T, reader io.ReaderAt, length int64) bool {
+func ImplementsReaderAt(t testing.TB, reader io.ReaderAt, length int64) bool {
 	return ImplementsReaderAtOpts(t, reader, length, defaultReaderAtOpts)
 }
 
 // ReaderAtOpts defines fine tunes controls for the ImplementsReaderAtOpts test.
 type ReaderAtOpts struct {
 	BufferSize int
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
 }
 
 // ImplementsReaderAtOpts uses providing options to perform ImplementsReaderAt.
-func ImplementsReaderAtOpts(t *testing.T, reader io.ReaderAt, length int64, opts ReaderAtOpts) bool {
-	var buf = make([]byte, opts.BufferSize)
-	var err error
-	var n int64
+func ImplementsReaderAtOpts(t testing.TB, reader io.ReaderAt, length int64, opts ReaderAtOpts) bool {
+	var ok = noopRead(t, "ReadAt", toReader(reader, 0), opts.Timeout) && readAtSequential(t, reader, opts)
 
-	if !noopRead(t, toReader(reader, 0)) {
-		return false
+	// The remaining properties are verified even if the reader failed sequential reads, so that every failure is
+	// reported.
+	if seeker, isSeeker := reader.(io.Seeker); isSeeker {
+		ok = readAtOffset(t, reader, seeker, opts.Timeout) && ok
+	}
+
+	if length > 0 {
+		checking(t, ReaderAtParallel)
+	}
+	grp, _ := errgroup.WithContext(context.Background())
+	for i := int64(0); i < length && i < 50; i++ {
+		i := i
+		grp.Go(func() error {
+			var buf = guarded(opts.BufferSize)
+			var err error
+			if !within(t, opts.Timeout, "ReadAt", func() { _, err = reader.ReadAt(buf, i) }, "len(p)=%d, off=%d", len(buf), i) {
+				return errFailed
+			}
+			if err != nil && !violated(t, ReaderAtParallel, "parallel ReadAt failed", "ReadAt(len(p)=%d, off=%d): %v", len(buf), i, err) {
+				return errFailed
+			}
+			if !checkGuard(t, "ReadAt", buf) {
+				return errFailed
+			}
+			return nil
+		})
 	}
 
+	return grp.Wait() == nil && ok
+}
+
+// readAtSequential reads the reader from start to end, verifying every call to ReadAt.
+func readAtSequential(t testing.TB, reader io.ReaderAt, opts ReaderAtOpts) bool {
+	checking(t, ReaderAtShort)
+	var buf = guarded(opts.BufferSize)
+	var err error
+	var n int64
+
 	for err == nil {
 		var a int
-		a, err = reader.ReadAt(buf, n)
-		n += int64(a)
-		if !(assert.GreaterOrEqual(t, a, 0) && assert.LessOrEqual(t, int64(opts.BufferSize), n)) {
+		if !within(t, opts.Timeout, "ReadAt", func() { a, err = reader.ReadAt(buf, n) }, "len(p)=%d, off=%d", len(buf), n) {
 			return false
 		}
+		if !(checkRead(t, "ReadAt", buf, a) && checkGuard(t, "ReadAt", buf)) {
+			return false
+		}
+		n += int64(a)
 
-		if 0 < n && n < int64(opts.BufferSize) {
-			return assert.Error(t, err)
+		if a < len(buf) && err == nil {
+			if !violated(t, ReaderAtShort, "ReadAt returned a short read without an error", "ReadAt returned %d of %d bytes", a, len(buf)) {
+				return false
+			}
+			if a == 0 {
+				// The reader is considered drained, as it would return (0, nil) forever.
+				return true
+			}
 		}
 	}
+	return checkEOF(t, "ReadAt", err)
+}
 
-	grp, _ := errgroup.WithContext(context.Background())
-	for i := int64(0); i < length && i < 50; i++ {
-		i := i
-		grp.Go(func() error {
-			var buf = make([]byte, opts.BufferSize)
-			_, err := reader.ReadAt(buf, i)
-			assert.NoError(t, err)
-			return err
-		})
+// readAtOffset verifies that ReadAt does not move the seek offset.
+func readAtOffset(t testing.TB, reader io.ReaderAt, seeker io.Seeker, timeout time.Duration) bool {
+	checking(t, ReaderAtOffset)
+	var before, after int64
+	var err error
+	if !within(t, timeout, "Seek", func() { before, err = seeker.Seek(0, io.SeekCurrent) }, "0, io.SeekCurrent") {
+		return false
+	}
+	if err != nil {
+		return violated(t, ReaderAtOffset, "Seek failed", "%v", err)
 	}
-	err2 := grp.Wait()
 
-	return assert.EqualError(t, err, io.EOF.Error()) && assert.NoError(t, err2)
+	var buf = make([]byte, 1)
+	if !within(t, timeout, "ReadAt", func() { _, _ = reader.ReadAt(buf, before+1) }, "len(p)=1, off=%d", before+1) {
+		return false
+	}
+
+	if !within(t, timeout, "Seek", func() { after, err = seeker.Seek(0, io.SeekCurrent) }, "0, io.SeekCurrent") {
+		return false
+	}
+	switch {
+	case err != nil:
+		return violated(t, ReaderAtOffset, "Seek failed", "%v", err)
+	case before != after:
+		return violated(t, ReaderAtOffset, "ReadAt moved the seek offset", "from %d to %d", before, after)
+	}
+	return true
 }
 
 type reader struct {
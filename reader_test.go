@@ -18,8 +18,11 @@ package iosemantic_test
 import (
 	"bytes"
 	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"testing"
+	"testing/iotest"
 )
 
 func TestImplementsReader(t *testing.T) {
@@ -31,3 +34,90 @@ func TestImplementsReaderOpts(t *testing.T) {
 	reader := bytes.NewBuffer(make([]byte, 4096*100))
 	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999}))
 }
+
+func TestImplementsReaderOptsZero(t *testing.T) {
+	reader := bytes.NewBuffer(make([]byte, 4096*100))
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{}))
+}
+
+func TestImplementsReaderOptsRetention(t *testing.T) {
+	reader := bytes.NewBuffer(make([]byte, 4096*100))
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999, DetectRetention: true}))
+}
+
+func TestImplementsReaderOptsEmptyReads(t *testing.T) {
+	reader := adversary.StallReader(bytes.NewBuffer(make([]byte, 4096*100)), 2)
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999, EmptyReads: 2, ShortReads: true}))
+}
+
+func TestImplementsReaderOptsMaxBytes(t *testing.T) {
+	reader, writer := io.Pipe()
+	go func() {
+		var err error
+		for err == nil {
+			_, err = writer.Write(make([]byte, 4096))
+		}
+	}()
+
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{
+		BufferSize: 1999,
+		MaxBytes:   4096 * 100,
+		Close:      true,
+		ShortReads: true,
+	}))
+}
+
+func TestImplementsReaderOptsStickyEOF(t *testing.T) {
+	reader := bytes.NewReader(make([]byte, 4096*100))
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999, StickyEOF: true}))
+}
+
+func TestImplementsReaderOptsShortReads(t *testing.T) {
+	reader := iotest.HalfReader(bytes.NewBuffer(make([]byte, 4096*100)))
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999, ShortReads: true}))
+}
+
+// panicAfterEOF panics when Read is called after it returned io.EOF, which io.Reader does not forbid.
+type panicAfterEOF struct {
+	reader io.Reader
+	eof    bool
+}
+
+func (p *panicAfterEOF) Read(buf []byte) (int, error) {
+	if p.eof {
+		panic("Read after io.EOF")
+	}
+	n, err := p.reader.Read(buf)
+	p.eof = err == io.EOF
+	return n, err
+}
+
+func TestImplementsReaderPanicAfterEOF(t *testing.T) {
+	reader := &panicAfterEOF{reader: bytes.NewReader(make([]byte, 4096*10))}
+	assert.True(t, iosemantic.ImplementsReader(t, reader))
+}
+
+// blockAfterEOF blocks until release is closed when Read is called after it returned io.EOF, which io.Reader does not
+// forbid.
+type blockAfterEOF struct {
+	reader  io.Reader
+	release chan struct{}
+	eof     bool
+}
+
+func (b *blockAfterEOF) Read(buf []byte) (int, error) {
+	if b.eof {
+		<-b.release
+		return 0, io.EOF
+	}
+	n, err := b.reader.Read(buf)
+	b.eof = err == io.EOF
+	return n, err
+}
+
+func TestImplementsReaderOptsBlockAfterEOF(t *testing.T) {
+	reader := &blockAfterEOF{reader: bytes.NewReader(make([]byte, 4096*10)), release: make(chan struct{})}
+	t.Cleanup(func() { close(reader.release) })
+	// The deadlines are disabled, but the sticky EOF probe is not enforced, so it gives up.
+	assert.True(t, iosemantic.ImplementsReaderOpts(t, reader, iosemantic.ReaderOpts{BufferSize: 1999}))
+}
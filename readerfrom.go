@@ -20,11 +20,10 @@ import (
 	"io"
 	"testing"
 	"testing/iotest"
-
-	"github.com/stretchr/testify/assert"
+	"time"
 )
 
-var defaultReaderFromOpts = ReaderFromOpts{BufferSize: 4096 * 100}
+var defaultReaderFromOpts = ReaderFromOpts{BufferSize: 4096 * 100, Timeout: defaultTimeout}
 
 // ImplementsReaderFrom verifies the following properties for a reader:
 //
@@ -32,22 +31,32 @@ var defaultReaderFromOpts = ReaderFromOpts{BufferSize: 4096 * 100}
 // 2. io.EOF is not returned.
 //
 // Use ImplementsReaderFromOpts for more control over the test suite.
-func ImplementsReaderFrom(t *testing.T, reader io.ReaderFrom) bool {
+func ImplementsReaderFrom(t testing.TB, reader io.ReaderFrom) bool {
 	return ImplementsReaderFromOpts(t, reader, defaultReaderFromOpts)
 }
 
 // ReaderFromOpts defines fine tunes controls for the ImplementsReaderFromOpts test.
 type ReaderFromOpts struct {
 	BufferSize int
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
 }
 
 // ImplementsReaderFromOpts uses providing options to perform ImplementsReaderFrom.
-func ImplementsReaderFromOpts(t *testing.T, reader io.ReaderFrom, opts ReaderFromOpts) bool {
+func ImplementsReaderFromOpts(t testing.TB, reader io.ReaderFrom, opts ReaderFromOpts) bool {
 	src := iotest.TimeoutReader(bytes.NewReader(make([]byte, opts.BufferSize)))
-	f, err := reader.ReadFrom(src)
-	if !assert.EqualError(t, err, iotest.ErrTimeout.Error()) {
+	var f, s int64
+	var err error
+	if !within(t, opts.Timeout, "ReadFrom", func() { f, err = reader.ReadFrom(src) }, "%T", src) {
+		return false
+	}
+	if !checkIs(t, ReaderFromError, err, iotest.ErrTimeout, "expected the error of the source, got %v", err) {
+		return false
+	}
+	if !within(t, opts.Timeout, "ReadFrom", func() { s, err = reader.ReadFrom(src) }, "%T", src) {
 		return false
 	}
-	s, err := reader.ReadFrom(src)
-	return assert.NoError(t, err) && assert.Equal(t, int(s+f), opts.BufferSize)
+	return noError(t, ReaderFromError, err, "ReadFrom failed after the source recovered") &&
+		holds(t, ReaderFromCount, int(s+f) == opts.BufferSize, "ReadFrom returned %d bytes in total, expected %d", s+f, opts.BufferSize)
 }
@@ -0,0 +1,235 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package reference contains an in-memory file, which follows the semantics of *os.File for the methods it
+// implements. It serves as a trusted fake, and as the model against which other implementations can be compared.
+package reference
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// errNegativeOffset is returned by ReadAt and WriteAt when called with a negative offset, as done by *os.File.
+var errNegativeOffset = errors.New("negative offset")
+
+// File is an in-memory file. Like *os.File, it is safe for concurrent use, although concurrent calls to Read, Write
+// and Seek race on the shared offset.
+//
+// The zero value is an empty, open file without a name.
+type File struct {
+	mu      sync.Mutex
+	name    string
+	data    []byte
+	offset  int64
+	modTime time.Time
+	closed  bool
+}
+
+// New returns an empty file with the given name.
+func New(name string) *File {
+	return &File{name: name, modTime: time.Now()}
+}
+
+// Name returns the name of the file as presented to New.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Bytes returns a copy of the content of the file.
+func (f *File) Bytes() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte(nil), f.data...)
+}
+
+// Read reads up to len(p) bytes from the current offset, and advances the offset by the number of bytes read. At end
+// of file, Read returns 0, io.EOF. A Read with an empty p returns 0, nil, even at end of file.
+func (f *File) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, f.wrapErr("read", os.ErrClosed)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if f.offset >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+// ReadAt reads len(p) bytes starting at off. It does not use or modify the offset. If fewer than len(p) bytes are
+// available, ReadAt returns the number of bytes read and io.EOF.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, f.wrapErr("read", os.ErrClosed)
+	}
+	if off < 0 {
+		return 0, f.wrapErr("readat", errNegativeOffset)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes p at the current offset, and advances the offset by len(p). Writing beyond the end of the file grows
+// it, and the gap between the old end and the offset reads as zeros.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, f.wrapErr("write", os.ErrClosed)
+	}
+	f.writeAt(p, f.offset)
+	f.offset += int64(len(p))
+	return len(p), nil
+}
+
+// WriteAt writes p at off. It does not use or modify the offset. Writing beyond the end of the file grows it, and
+// the gap between the old end and off reads as zeros.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, f.wrapErr("write", os.ErrClosed)
+	}
+	if off < 0 {
+		return 0, f.wrapErr("writeat", errNegativeOffset)
+	}
+	f.writeAt(p, off)
+	return len(p), nil
+}
+
+// writeAt copies p into the file at off, growing the file when needed. An empty p does not grow the file.
+func (f *File) writeAt(p []byte, off int64) {
+	if len(p) == 0 {
+		return
+	}
+	if end := off + int64(len(p)); end > int64(len(f.data)) {
+		f.resize(end)
+	}
+	copy(f.data[off:], p)
+	f.modTime = time.Now()
+}
+
+// Seek sets the offset for the next Read or Write to offset, interpreted according to whence: io.SeekStart means
+// relative to the start of the file, io.SeekCurrent relative to the current offset, and io.SeekEnd relative to the
+// end. Seeking beyond the end of the file is allowed; seeking to a negative offset is an error.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, f.wrapErr("seek", os.ErrClosed)
+	}
+
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = f.offset + offset
+	case io.SeekEnd:
+		abs = int64(len(f.data)) + offset
+	default:
+		return 0, f.wrapErr("seek", os.ErrInvalid)
+	}
+	if abs < 0 {
+		return 0, f.wrapErr("seek", os.ErrInvalid)
+	}
+	f.offset = abs
+	return abs, nil
+}
+
+// Truncate changes the size of the file. It does not change the offset. Growing the file appends zeros.
+func (f *File) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return f.wrapErr("truncate", os.ErrClosed)
+	}
+	if size < 0 {
+		return f.wrapErr("truncate", os.ErrInvalid)
+	}
+	f.resize(size)
+	f.modTime = time.Now()
+	return nil
+}
+
+// resize grows or shrinks the file to size, zeroing any newly exposed bytes.
+func (f *File) resize(size int64) {
+	if size <= int64(cap(f.data)) {
+		old := len(f.data)
+		f.data = f.data[:size]
+		for i := old; i < len(f.data); i++ {
+			f.data[i] = 0
+		}
+		return
+	}
+	data := make([]byte, size, size+size/4)
+	copy(data, f.data)
+	f.data = data
+}
+
+// Stat returns the FileInfo describing the file.
+func (f *File) Stat() (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, f.wrapErr("stat", os.ErrClosed)
+	}
+	return fileInfo{name: f.name, size: int64(len(f.data)), modTime: f.modTime}, nil
+}
+
+// Close closes the file. Every method but Name and Bytes returns an error wrapping os.ErrClosed afterwards, including
+// Close itself.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return f.wrapErr("close", os.ErrClosed)
+	}
+	f.closed = true
+	return nil
+}
+
+// wrapErr wraps err in an *os.PathError, as done by *os.File.
+func (f *File) wrapErr(op string, err error) error {
+	return &os.PathError{Op: op, Path: f.name, Err: err}
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package reference_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/reference"
+)
+
+func TestFileImplementsReaderAndWriter(t *testing.T) {
+	file := reference.New("reader")
+	assert.True(t, iosemantic.ImplementsWriter(t, file))
+
+	_, err := file.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.True(t, iosemantic.ImplementsReader(t, file))
+}
+
+func TestFileImplementsReaderAtAndWriterAt(t *testing.T) {
+	var length int64 = 4096 * 100
+	file := reference.New("at")
+	assert.True(t, iosemantic.ImplementsWriterAt(t, file, length))
+
+	assert.NoError(t, file.Truncate(length))
+	assert.True(t, iosemantic.ImplementsReaderAt(t, file, length))
+}
+
+func TestFileHoles(t *testing.T) {
+	file := reference.New("holes")
+
+	n, err := file.WriteAt([]byte("end"), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	_, err = file.Seek(10, io.SeekStart)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("!"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x00\x00\x00\x00\x00end\x00\x00!"), file.Bytes())
+
+	assert.NoError(t, file.Truncate(2))
+	assert.NoError(t, file.Truncate(4))
+	assert.Equal(t, make([]byte, 4), file.Bytes())
+
+	info, err := file.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size())
+	assert.Equal(t, "holes", info.Name())
+}
+
+func TestFileEOF(t *testing.T) {
+	file := reference.New("eof")
+	_, err := file.Write([]byte("abc"))
+	assert.NoError(t, err)
+
+	var buf = make([]byte, 4)
+	n, err := file.Read(buf)
+	assert.Zero(t, n)
+	assert.Equal(t, io.EOF, err)
+
+	n, err = file.Read(buf[:0])
+	assert.Zero(t, n)
+	assert.NoError(t, err)
+
+	n, err = file.ReadAt(buf, 1)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.EOF, err)
+
+	_, err = file.Seek(-1, io.SeekStart)
+	assert.True(t, errors.Is(err, os.ErrInvalid))
+
+	assert.NoError(t, file.Close())
+	_, err = file.Read(buf)
+	assert.True(t, errors.Is(err, os.ErrClosed))
+	assert.True(t, errors.Is(file.Close(), os.ErrClosed))
+}
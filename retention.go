@@ -0,0 +1,81 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// scribbleByte is written over buffers once a call has returned, as the implementation must not retain them.
+const scribbleByte = 0xa5
+
+// scribble overwrites p with scribbleByte.
+func scribble(p []byte) {
+	for i := range p {
+		p[i] = scribbleByte
+	}
+}
+
+// fill writes a recognizable pattern to p, which differs from scribbleByte at most offsets.
+func fill(p []byte) {
+	for i := range p {
+		p[i] = byte(i % 251)
+	}
+}
+
+// notRetained verifies that every buffer in scribbled still only contains scribbleByte, and was thus not written to
+// after the call it was passed to returned.
+func notRetained(t testing.TB, op string, scribbled [][]byte) bool {
+	if len(scribbled) > 0 {
+		checking(t, clauseOf(op, "retention"))
+	}
+	for i, p := range scribbled {
+		for j, b := range p {
+			if b != scribbleByte {
+				return violated(t, clauseOf(op, "retention"), op+" retained p",
+					"the buffer passed to call %d of %s was modified at offset %d after %s returned", i+1, op, j, op)
+			}
+		}
+	}
+	return true
+}
+
+// readback verifies that the bytes stored by a writer equal the bytes written, after flushing the writer if it has a
+// Flush() error method. op is the method being verified, which is Write or WriteAt.
+func readback(t testing.TB, op string, writer interface{}, fn func() []byte, written []byte, timeout time.Duration) bool {
+	checking(t, clauseOf(op, "retention"))
+	var err error
+	if flusher, ok := writer.(interface{ Flush() error }); ok {
+		if !within(t, timeout, "Flush", func() { err = flusher.Flush() }, "") {
+			return false
+		}
+		if err != nil && !violated(t, clauseOf(op, "accept"), "Flush failed", "%v", err) {
+			return false
+		}
+	}
+
+	var stored []byte
+	if !within(t, timeout, "Readback", func() { stored = fn() }, "") {
+		return false
+	}
+	if !bytes.Equal(written, stored) {
+		return violated(t, clauseOf(op, "retention"), "the bytes read back differ from the bytes written",
+			"the writer may have retained p after %s returned", op)
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var defaultSplitOpts = SplitOpts{
+	Runs:       10,
+	BufferSize: 4096,
+	Size:       4096 * 100,
+}
+
+// SplitFactory constructs the fresh instances compared by ImplementsSplitInvariance. Either field may be nil, in
+// which case that half of the test is skipped.
+type SplitFactory struct {
+	// NewReader returns a new reader over the same content on every call.
+	NewReader func() io.Reader
+	// NewWriter returns a new writer writing to dst. If the writer has a Flush() error or Close() error method, it is
+	// called after the last Write.
+	NewWriter func(dst io.Writer) io.Writer
+}
+
+// ImplementsSplitInvariance verifies the following properties:
+//
+// 1. a reader produces the same bytes and final error, no matter the lengths of the buffers passed to Read.
+// 2. a writer produces the same output, no matter how its input is split across calls to Write.
+//
+// Outputs are compared with each other, so no expected content needs to be provided.
+// Use ImplementsSplitInvarianceOpts for more control over the test suite.
+func ImplementsSplitInvariance(t testing.TB, factory SplitFactory) bool {
+	return ImplementsSplitInvarianceOpts(t, factory, defaultSplitOpts)
+}
+
+// SplitOpts defines fine tunes controls for the ImplementsSplitInvarianceOpts test. Unset fields are set to
+// defaults.
+type SplitOpts struct {
+	// Runs is the number of randomly split reads and writes compared against the unsplit one.
+	Runs int
+	// BufferSize is the maximum length of a single Read buffer, and the length of the unsplit Read buffer.
+	BufferSize int
+	// Size is the number of bytes written to the writer.
+	Size int
+	// Seed seeds the random split points. If zero, a time based seed is used and logged.
+	Seed int64
+	// Timeout is the deadline of a single call into the reader or writer, defaulting to 10 seconds. A run with a call
+	// which does not return fails with a dump of all goroutines, and the next run starts. A negative Timeout disables
+	// the deadline.
+	Timeout time.Duration
+}
+
+// ImplementsSplitInvarianceOpts uses providing options to perform ImplementsSplitInvariance.
+func ImplementsSplitInvarianceOpts(t testing.TB, factory SplitFactory, opts SplitOpts) (ok bool) {
+	defer catch(t, "ImplementsSplitInvarianceOpts", &ok)
+
+	if opts.Runs <= 0 {
+		opts.Runs = defaultSplitOpts.Runs
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultSplitOpts.BufferSize
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultSplitOpts.Size
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	opts.Timeout = timeoutOf(opts.Timeout)
+	t.Logf("ImplementsSplitInvariance seed: %d", opts.Seed)
+	var rnd = rand.New(rand.NewSource(opts.Seed))
+
+	// The writer is verified even if the reader failed, so that every failure is reported.
+	ok = true
+	if factory.NewReader != nil {
+		ok = readSplitInvariance(t, factory.NewReader, rnd, opts)
+	}
+	if factory.NewWriter != nil {
+		ok = writeSplitInvariance(t, factory.NewWriter, rnd, opts) && ok
+	}
+	return ok
+}
+
+// readSplitInvariance drains fresh readers with random buffer lengths, and compares them against a drain using
+// buffers of opts.BufferSize.
+func readSplitInvariance(t testing.TB, factory func() io.Reader, rnd *rand.Rand, opts SplitOpts) bool {
+	want, wantErr := drain(t, opts.Timeout, factory(), func() int { return opts.BufferSize })
+	if wantErr == errViolation {
+		return false
+	}
+
+	var ok = true
+	for i := 0; i < opts.Runs; i++ {
+		got, err := drain(t, opts.Timeout, factory(), func() int { return rnd.Intn(opts.BufferSize) + 1 })
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !(holds(t, SplitReader, err == wantErr, "run %d: final error differs, expected %v, got %v", i, wantErr, err) &&
+			holds(t, SplitReader, bytes.Equal(want, got), "run %d: read %d bytes, expected %d identical bytes", i, len(got), len(want))) {
+			return false
+		}
+	}
+	return ok
+}
+
+// drain reads from reader until an error is returned, using buffers of the lengths returned by size. Like bufio, drain
+// returns io.ErrNoProgress once Read returned (0, nil) defaultEmptyReads times in a row. If a call to Read panics or
+// does not return within timeout, drain reports it and returns errViolation.
+func drain(t testing.TB, timeout time.Duration, reader io.Reader, size func() int) ([]byte, error) {
+	var out []byte
+	var empty int
+	for {
+		var buf = make([]byte, size())
+		var n int
+		var err error
+		if !within(t, timeout, "Read", func() { n, err = reader.Read(buf) }, "len(p)=%d", len(buf)) {
+			return out, errViolation
+		}
+		out = append(out, buf[:clamp(n, len(buf))]...)
+		if err != nil {
+			return out, err
+		}
+		if n > 0 {
+			empty = 0
+			continue
+		}
+		empty++
+		if empty >= defaultEmptyReads {
+			return out, io.ErrNoProgress
+		}
+	}
+}
+
+// writeSplitInvariance writes the same random data to fresh writers using random split points, and compares the
+// output against writing the data in a single call.
+func writeSplitInvariance(t testing.TB, factory func(io.Writer) io.Writer, rnd *rand.Rand, opts SplitOpts) bool {
+	var data = make([]byte, opts.Size)
+	rnd.Read(data)
+
+	want, err := writeSplit(t, opts.Timeout, factory, data, func(remaining int) int { return remaining })
+	if err == errViolation || !noError(t, SplitWriter, err, "writing in a single call failed") {
+		return false
+	}
+
+	var ok = true
+	for i := 0; i < opts.Runs; i++ {
+		got, err := writeSplit(t, opts.Timeout, factory, data, func(remaining int) int { return rnd.Intn(remaining) + 1 })
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !(noError(t, SplitWriter, err, "run %d failed", i) &&
+			holds(t, SplitWriter, bytes.Equal(want, got), "run %d: wrote %d bytes, expected %d identical bytes", i, len(got), len(want))) {
+			return false
+		}
+	}
+	return ok
+}
+
+// writeSplit writes data to a new writer in chunks of the lengths returned by size, and returns the output. If a call
+// panics or does not return within timeout, writeSplit reports it and returns errViolation.
+func writeSplit(t testing.TB, timeout time.Duration, factory func(io.Writer) io.Writer, data []byte, size func(remaining int) int) ([]byte, error) {
+	var dst bytes.Buffer
+	var writer = factory(&dst)
+	for len(data) > 0 {
+		var chunk = data[:size(len(data))]
+		var n int
+		var err error
+		if !within(t, timeout, "Write", func() { n, err = writer.Write(chunk) }, "len(p)=%d", len(chunk)) {
+			return nil, errViolation
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n < len(chunk) {
+			return nil, io.ErrShortWrite
+		}
+		data = data[len(chunk):]
+	}
+	if err := finish(t, timeout, writer); err != nil {
+		return nil, err
+	}
+	return dst.Bytes(), nil
+}
+
+// finish flushes and closes writer, if it supports doing so. If a call panics or does not return within timeout,
+// finish reports it and returns errViolation.
+func finish(t testing.TB, timeout time.Duration, writer io.Writer) error {
+	var err error
+	if flusher, ok := writer.(interface{ Flush() error }); ok {
+		if !within(t, timeout, "Flush", func() { err = flusher.Flush() }, "") {
+			return errViolation
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		if !within(t, timeout, "Close", func() { err = closer.Close() }, "") {
+			return errViolation
+		}
+	}
+	return err
+}
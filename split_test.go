@@ -0,0 +1,61 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func TestImplementsSplitInvariance(t *testing.T) {
+	var content = make([]byte, 4096*100+7)
+	assert.True(t, iosemantic.ImplementsSplitInvariance(t, iosemantic.SplitFactory{
+		NewReader: func() io.Reader { return bytes.NewReader(content) },
+		NewWriter: func(dst io.Writer) io.Writer { return bufio.NewWriter(dst) },
+	}))
+}
+
+func TestImplementsSplitInvarianceOpts(t *testing.T) {
+	var content bytes.Buffer
+	var gz = gzip.NewWriter(&content)
+	_, err := gz.Write(bytes.Repeat([]byte("iosemantic"), 1000))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	assert.True(t, iosemantic.ImplementsSplitInvarianceOpts(t, iosemantic.SplitFactory{
+		NewReader: func() io.Reader {
+			r, err := gzip.NewReader(bytes.NewReader(content.Bytes()))
+			assert.NoError(t, err)
+			return r
+		},
+		NewWriter: func(dst io.Writer) io.Writer { return gzip.NewWriter(dst) },
+	}, iosemantic.SplitOpts{Runs: 20, BufferSize: 333, Size: 10000, Seed: 7}))
+}
+
+func TestImplementsSplitInvarianceOptsDefaults(t *testing.T) {
+	var content = make([]byte, 4096*10)
+	assert.True(t, iosemantic.ImplementsSplitInvarianceOpts(t, iosemantic.SplitFactory{
+		NewReader: func() io.Reader { return bytes.NewReader(content) },
+		NewWriter: func(dst io.Writer) io.Writer { return bufio.NewWriter(dst) },
+	}, iosemantic.SplitOpts{Runs: 3}))
+}
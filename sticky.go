@@ -0,0 +1,106 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// stickyCalls is the number of calls made after a terminal result, to verify that the result is repeated.
+const stickyCalls = 3
+
+// probeTimeout is the deadline of a call probing a clause which is not enforced. Such a call may legally block, so it
+// is bounded even if the deadlines of the check are disabled.
+const probeTimeout = time.Second
+
+// checkStickyEOF calls Read after it returned io.EOF, and verifies that it keeps returning (0, io.EOF). The io.Reader
+// documentation does not require this, so if enforce is false, whether it holds is only logged.
+func checkStickyEOF(t testing.TB, reader io.Reader, enforce bool, timeout time.Duration) bool {
+	enforce = enforce || enforced(t, ReaderStickyEOF)
+	var buf = guarded(512)
+	for i := 0; i < stickyCalls; i++ {
+		var n int
+		var err error
+		if !probe(t, ReaderStickyEOF, enforce, timeout, "Read", func() { n, err = reader.Read(buf) }, "len(p)=%d", len(buf)) {
+			return !enforce
+		}
+		if !checkRead(t, "Read", buf, n) {
+			return false
+		}
+		if n != 0 || err != io.EOF {
+			return sticky(t, ReaderStickyEOF, enforce, fmt.Sprintf("Read returned (%d, %v) after io.EOF", n, err))
+		}
+	}
+	return sticky(t, ReaderStickyEOF, enforce, "")
+}
+
+// checkStickyWrite calls Write with p after it failed with first, and verifies that it keeps failing with first,
+// matched using errors.Is. op is the method being verified, which writer calls. The io.Writer documentation does not
+// require this, so if enforce is false, whether it holds is only logged.
+func checkStickyWrite(t testing.TB, op string, writer io.Writer, p []byte, first error, enforce bool, timeout time.Duration) bool {
+	var c = clauseOf(op, "sticky-error")
+	enforce = enforce || enforced(t, c)
+	for i := 0; i < stickyCalls; i++ {
+		var n int
+		var err error
+		if !probe(t, c, enforce, timeout, op, func() { n, err = writer.Write(p) }, "len(p)=%d", len(p)) {
+			return !enforce
+		}
+		if !checkWrite(t, op, p, n, err) {
+			return false
+		}
+		if !errors.Is(err, first) {
+			return sticky(t, c, enforce, fmt.Sprintf("%s returned (%d, %v) after failing with %v", op, n, err, first))
+		}
+	}
+	return sticky(t, c, enforce, "")
+}
+
+// probe calls fn like within, to verify the optional clause c. If c is not enforced, a call which panics or does not
+// return within probeTimeout is logged instead of failing the test, as the implementation is only probed to log
+// whether c holds. probe returns false if the call did not return normally, in which case the caller must stop
+// probing.
+func probe(t testing.TB, c Clause, enforce bool, timeout time.Duration, op string, fn func(), format string, args ...interface{}) bool {
+	if enforce {
+		return within(t, timeout, op, fn, format, args...)
+	}
+	if timeout <= 0 || timeout > probeTimeout {
+		timeout = probeTimeout
+	}
+	if v := returns(timeout, op, fn, format, args...); v != nil {
+		t.Logf("[%s] %s while verifying [%s], which is not enforced\n%s", v.Clause, v.Message, c, v.Stack)
+		return false
+	}
+	return true
+}
+
+// sticky reports the outcome of the optional clause, which was broken if message is not empty. The clause is enforced
+// if enforce is set, or if the profile of t enforces it.
+func sticky(t testing.TB, c Clause, enforce bool, message string) bool {
+	checking(t, c)
+	enforce = enforce || enforced(t, c)
+	if message != "" {
+		return violation(t, c, enforce, "does not hold", message)
+	}
+	if !enforce {
+		t.Logf("[%s] holds, although it is not enforced", c)
+	}
+	return true
+}
@@ -0,0 +1,335 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/kaiserkarel/iosemantic/adversary"
+)
+
+// errSource is returned by the failing sources of ImplementsReaderWrapper.
+var errSource = errors.New("iosemantic: source failed")
+
+// ReaderWrapperOpts defines fine tunes controls for the ImplementsReaderWrapper test. Unset fields are set to
+// defaults.
+type ReaderWrapperOpts struct {
+	// BufferSize is the length of the buffers passed to the wrapped reader, defaulting to 4096.
+	BufferSize int
+	// Source is the content read by the wrapper, for example a compressed stream for a decompressor. Defaults to
+	// 4096 * 10 zeros.
+	Source []byte
+	// UnexpectedEOF is set if the format demands io.ErrUnexpectedEOF when the source ends early.
+	UnexpectedEOF bool
+	// Timeout is the deadline of a single call into the wrapped reader, defaulting to 10 seconds. A call which does
+	// not return fails with a dump of all goroutines, and the check continues with the next source. A negative
+	// Timeout disables the deadline.
+	Timeout time.Duration
+}
+
+// ImplementsReaderWrapper verifies the following properties for a reader wrapping another reader:
+//
+// 1. the output is identical for every well behaved source, and the wrapped reader passes ImplementsReaderOpts.
+// 2. an error returned by the source is returned by the wrapped reader, matched using errors.Is.
+// 3. if opts.UnexpectedEOF is set, a source ending early results in io.ErrUnexpectedEOF.
+// 4. if the profile enforces ReaderWrapperAvailable, Read returns the data which is available instead of waiting.
+//
+// The well behaved sources return a single byte per call, return data together with io.EOF, or interleave (0, nil)
+// results. The output of the wrapped reader before an error is expected to be a prefix of its complete output.
+//
+// The fourth property is verified using a source which returns the first half of opts.Source, and then blocks. The
+// wrapped reader must return data within a second, when called with a buffer large enough to hold all of opts.Source.
+func ImplementsReaderWrapper(t testing.TB, wrap func(io.Reader) io.Reader, opts ReaderWrapperOpts) (ok bool) {
+	defer catch(t, "ImplementsReaderWrapper", &ok)
+
+	if opts.BufferSize == 0 {
+		opts.BufferSize = 4096
+	}
+	if opts.Source == nil {
+		opts.Source = make([]byte, 4096*10)
+	}
+	opts.Timeout = timeoutOf(opts.Timeout)
+
+	// The outputs of the other sources are compared against want, so the comparisons are skipped if reading it
+	// failed. A source whose reader panics or does not return is skipped, and the check continues with the next.
+	var size = func() int { return opts.BufferSize }
+	want, err := drain(t, opts.Timeout, wrap(bytes.NewReader(opts.Source)), size)
+	var compare = err != errViolation
+	if compare && !holds(t, ReaderWrapperOutput, err == io.EOF, "wrapping a bytes.Reader: expected io.EOF, got %v", err) {
+		return false
+	}
+	ok = compare
+
+	var sources = []struct {
+		name string
+		new  func() io.Reader
+	}{
+		{"iotest.OneByteReader", func() io.Reader { return iotest.OneByteReader(bytes.NewReader(opts.Source)) }},
+		{"iotest.HalfReader", func() io.Reader { return iotest.HalfReader(bytes.NewReader(opts.Source)) }},
+		{"adversary.DataErrReader", func() io.Reader { return adversary.DataErrReader(bytes.NewReader(opts.Source), io.EOF) }},
+		{"adversary.StallReader", func() io.Reader { return adversary.StallReader(bytes.NewReader(opts.Source), 2) }},
+	}
+	for _, source := range sources {
+		got, err := drain(t, opts.Timeout, wrap(source.new()), size)
+		switch {
+		case err == errViolation:
+			ok = false
+		case !(holds(t, ReaderWrapperOutput, err == io.EOF, "wrapping %s: expected io.EOF, got %v", source.name, err) &&
+			(!compare || holds(t, ReaderWrapperOutput, bytes.Equal(want, got), "wrapping %s: output differs", source.name))):
+			return false
+		}
+		ok = ImplementsReaderOpts(t, wrap(source.new()), ReaderOpts{BufferSize: opts.BufferSize, Timeout: opts.Timeout, ShortReads: true}) && ok
+	}
+
+	var failing = []struct {
+		name string
+		new  func() io.Reader
+	}{
+		{"iotest.ErrReader", func() io.Reader { return iotest.ErrReader(errSource) }},
+		{"a source failing halfway", func() io.Reader {
+			return io.MultiReader(bytes.NewReader(opts.Source[:len(opts.Source)/2]), iotest.ErrReader(errSource))
+		}},
+	}
+	for _, source := range failing {
+		got, err := drain(t, opts.Timeout, wrap(source.new()), size)
+		switch {
+		case err == errViolation:
+			ok = false
+		case !(checkIs(t, ReaderWrapperError, err, errSource, "wrapping %s: expected the source error, got %v", source.name, err) &&
+			(!compare || holds(t, ReaderWrapperOutput, bytes.HasPrefix(want, got), "wrapping %s: output is not a prefix of the complete output", source.name))):
+			return false
+		}
+	}
+
+	got, err := drain(t, opts.Timeout, wrap(bytes.NewReader(opts.Source[:len(opts.Source)/2])), size)
+	switch {
+	case err == errViolation:
+		ok = false
+	case opts.UnexpectedEOF && !checkIs(t, ReaderWrapperUnexpectedEOF, err, io.ErrUnexpectedEOF,
+		"wrapping a source ending early: expected io.ErrUnexpectedEOF, got %v", err):
+		return false
+	case compare && !holds(t, ReaderWrapperOutput, bytes.HasPrefix(want, got),
+		"wrapping a source ending early: output is not a prefix of the complete output"):
+		return false
+	}
+	if enforced(t, ReaderWrapperAvailable) {
+		ok = readAvailable(t, wrap, opts) && ok
+	}
+	return ok
+}
+
+// availableTimeout is the time the wrapped reader is given by readAvailable to return the data which is available.
+const availableTimeout = time.Second
+
+// readAvailable wraps a source which returns the first half of opts.Source and then blocks, and verifies that the
+// wrapped reader returns data instead of waiting for the remainder to fill p.
+func readAvailable(t testing.TB, wrap func(io.Reader) io.Reader, opts ReaderWrapperOpts) bool {
+	checking(t, ReaderWrapperAvailable)
+	src, dst := io.Pipe()
+	defer dst.CloseWithError(errSource)
+	go func() { _, _ = dst.Write(opts.Source[:len(opts.Source)/2]) }()
+
+	type result struct {
+		n        int
+		err      error
+		panicked *Violation
+	}
+	var done = make(chan result, 1)
+	checking(t, CallNoPanic)
+	go func() {
+		var res result
+		res.panicked = recovered("Read", func() {
+			var reader = wrap(src)
+			var buf = make([]byte, len(opts.Source))
+			for i := 0; i < defaultEmptyReads; i++ {
+				if res.n, res.err = reader.Read(buf); res.n > 0 || res.err != nil {
+					return
+				}
+			}
+			res.err = io.ErrNoProgress
+		}, "len(p)=%d", len(opts.Source))
+		done <- res
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicked != nil {
+			return failed(t, CallNoPanic, res.panicked.Message, "%s", res.panicked.Stack)
+		}
+		return holds(t, ReaderWrapperAvailable, res.n > 0,
+			"wrapping a source returning %d bytes: Read returned no data, but %v", len(opts.Source)/2, res.err)
+	case <-time.After(availableTimeout):
+		return violated(t, ReaderWrapperAvailable, fmt.Sprintf(
+			"wrapping a source returning %d bytes and blocking: Read did not return after %s", len(opts.Source)/2, availableTimeout))
+	}
+}
+
+// errDestination is returned by the failing destinations of ImplementsWriterWrapper.
+var errDestination = errors.New("iosemantic: destination failed")
+
+var defaultWriterWrapperOpts = WriterWrapperOpts{
+	BufferSize: 4096,
+	Size:       4096 * 100,
+}
+
+// ImplementsWriterWrapper verifies the following properties for a writer wrapping another writer:
+//
+// 1. the wrapped writer passes ImplementsWriter, and every call to Write satisfies its properties.
+// 2. an error returned by the destination is returned by Write, Flush or Close, matched using errors.Is.
+// 3. a short write without an error by the destination results in io.ErrShortWrite from Write, Flush or Close.
+//
+// The destinations fail on the third call, accept one byte per call, or write half of p without returning an error.
+// Flush is called if the wrapped writer has a Flush() error method. Use ImplementsWriterWrapperOpts for more control
+// over the test suite.
+func ImplementsWriterWrapper(t testing.TB, wrap func(io.Writer) io.WriteCloser) bool {
+	return ImplementsWriterWrapperOpts(t, wrap, defaultWriterWrapperOpts)
+}
+
+// WriterWrapperOpts defines fine tunes controls for the ImplementsWriterWrapperOpts test. Unset fields are set to
+// defaults.
+type WriterWrapperOpts struct {
+	// BufferSize is the length of the buffers passed to the wrapped writer, defaulting to 4096.
+	BufferSize int
+	// Size is the number of bytes written to the wrapped writer, defaulting to 4096 * 100.
+	Size int
+	// StickyWriteError requires that once Write failed, every later call fails with the same error, matched using
+	// errors.Is. Many consumers assume this, as bufio.Writer does, but io.Writer does not require it. If unset, and
+	// the profile does not enforce the clause, whether it holds is logged.
+	StickyWriteError bool
+	// Timeout is the deadline of a single call into the wrapped writer, defaulting to 10 seconds. A call which does
+	// not return fails with a dump of all goroutines, and the check continues with the next destination. A negative
+	// Timeout disables the deadline.
+	Timeout time.Duration
+}
+
+// ImplementsWriterWrapperOpts uses providing options to perform ImplementsWriterWrapper.
+func ImplementsWriterWrapperOpts(t testing.TB, wrap func(io.Writer) io.WriteCloser, opts WriterWrapperOpts) (ok bool) {
+	defer catch(t, "ImplementsWriterWrapperOpts", &ok)
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultWriterWrapperOpts.BufferSize
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultWriterWrapperOpts.Size
+	}
+	opts.Timeout = timeoutOf(opts.Timeout)
+
+	// The destinations are verified even if the wrapped writer failed, so that every failure is reported.
+	var writer = wrap(io.Discard)
+	var err error
+	ok = ImplementsWriterOpts(t, writer, WriterOpts{BufferSize: opts.Size, Timeout: opts.Timeout}) &&
+		within(t, opts.Timeout, "Close", func() { err = writer.Close() }, "") &&
+		noError(t, WriterWrapperError, err, "Close failed")
+
+	var destinations = []struct {
+		name string
+		dst  io.Writer
+		want error
+		c    Clause
+	}{
+		{"a destination failing on the third call", adversary.FailingWriter(io.Discard, 3, errDestination), errDestination,
+			WriterWrapperError},
+		{"a destination accepting one byte per call", adversary.OneByteWriter(io.Discard), io.ErrShortWrite,
+			WriterWrapperShortWrite},
+		{"a destination writing half of p without an error", halfWriter{io.Discard}, io.ErrShortWrite,
+			WriterWrapperShortWrite},
+	}
+	for _, destination := range destinations {
+		var dst = &observedWriter{writer: destination.dst}
+		err := writeWrapped(t, wrap(dst), opts)
+		if err == errViolation {
+			ok = false
+			continue
+		}
+		if !dst.failed {
+			continue
+		}
+		if !checkIs(t, destination.c, err, destination.want,
+			"wrapping %s: expected %v from Write, Flush or Close, got %v", destination.name, destination.want, err) {
+			return false
+		}
+	}
+	return ok
+}
+
+// writeWrapped writes opts.Size bytes to writer, flushes and closes it, and returns the first error encountered. If a
+// call violated the properties of ImplementsWriter, panicked or did not return within opts.Timeout, writeWrapped
+// reports it and returns errViolation.
+func writeWrapped(t testing.TB, writer io.WriteCloser, opts WriterWrapperOpts) error {
+	var buf = make([]byte, opts.BufferSize)
+	for written := 0; written < opts.Size; {
+		var chunk = buf
+		if opts.Size-written < len(chunk) {
+			chunk = chunk[:opts.Size-written]
+		}
+		var n int
+		var err error
+		if !(within(t, opts.Timeout, "Write", func() { n, err = writer.Write(chunk) }, "len(p)=%d", len(chunk)) &&
+			checkWrite(t, "Write", chunk, n, err)) {
+			return errViolation
+		}
+		if err != nil {
+			if !checkStickyWrite(t, "Write", writer, chunk, err, opts.StickyWriteError, opts.Timeout) {
+				return errViolation
+			}
+			return closeWrapped(t, writer, opts.Timeout, err)
+		}
+		if n == 0 {
+			// A profile which does not enforce WriterShort lets (0, nil) pass, which would never finish.
+			return closeWrapped(t, writer, opts.Timeout, io.ErrShortWrite)
+		}
+		written += n
+	}
+	return finish(t, opts.Timeout, writer)
+}
+
+// closeWrapped closes writer once writing failed with err, and returns err. Errors returned by Close are ignored, but
+// a call which panics or does not return within timeout is reported, and errViolation is returned.
+func closeWrapped(t testing.TB, writer io.Closer, timeout time.Duration, err error) error {
+	if !within(t, timeout, "Close", func() { _ = writer.Close() }, "") {
+		return errViolation
+	}
+	return err
+}
+
+// halfWriter writes half of p to the underlying writer without returning an error.
+type halfWriter struct {
+	writer io.Writer
+}
+
+func (h halfWriter) Write(p []byte) (int, error) {
+	return h.writer.Write(p[:len(p)/2])
+}
+
+// observedWriter records whether the underlying writer failed, or wrote fewer than len(p) bytes.
+type observedWriter struct {
+	writer io.Writer
+	failed bool
+}
+
+func (o *observedWriter) Write(p []byte) (int, error) {
+	n, err := o.writer.Write(p)
+	if err != nil || n < len(p) {
+		o.failed = true
+	}
+	return n, err
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Karel L. Kubat
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+// documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit
+// persons to whom the Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+// Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+// WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package iosemantic_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaiserkarel/iosemantic"
+)
+
+func TestImplementsReaderWrapper(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsReaderWrapper(t, func(r io.Reader) io.Reader {
+		return io.LimitReader(r, 30000)
+	}, iosemantic.ReaderWrapperOpts{}))
+}
+
+func TestImplementsReaderWrapperUnexpectedEOF(t *testing.T) {
+	var source bytes.Buffer
+	var gz = gzip.NewWriter(&source)
+	_, err := gz.Write(bytes.Repeat([]byte("iosemantic"), 10000))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	assert.True(t, iosemantic.ImplementsReaderWrapper(t, func(r io.Reader) io.Reader {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return iotest.ErrReader(err)
+		}
+		return gz
+	}, iosemantic.ReaderWrapperOpts{BufferSize: 999, Source: source.Bytes(), UnexpectedEOF: true}))
+}
+
+// bufferedWriter closes a bufio.Writer by flushing it.
+type bufferedWriter struct {
+	*bufio.Writer
+}
+
+func (b bufferedWriter) Close() error {
+	return b.Flush()
+}
+
+func TestImplementsWriterWrapper(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsWriterWrapper(t, func(w io.Writer) io.WriteCloser {
+		return bufferedWriter{bufio.NewWriter(w)}
+	}))
+}
+
+func TestImplementsWriterWrapperOpts(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsWriterWrapperOpts(t, func(w io.Writer) io.WriteCloser {
+		return bufferedWriter{bufio.NewWriterSize(w, 1024)}
+	}, iosemantic.WriterWrapperOpts{BufferSize: 1000, Size: 100000}))
+}
+
+func TestImplementsWriterWrapperOptsDefaults(t *testing.T) {
+	assert.True(t, iosemantic.ImplementsWriterWrapperOpts(t, func(w io.Writer) io.WriteCloser {
+		return bufferedWriter{bufio.NewWriter(w)}
+	}, iosemantic.WriterWrapperOpts{Size: 100}))
+}
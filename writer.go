@@ -18,49 +18,132 @@ package iosemantic
 import (
 	"io"
 	"testing"
-
-	"github.com/stretchr/testify/assert"
+	"time"
 )
 
 // DefaultWriterOpts are the options used by ImplementsWriter.
 var defaultWriterOpts = WriterOpts{
 	BufferSize: 4096 * 100,
+	Timeout:    defaultTimeout,
 }
 
 // ImplementsWriter verifies the following properties for a writer:
 //
 // 1. 0 <= n <= len(p) where p is the buffer being written from.
 // 2. if n < len(p), err != nil.
+// 3. Write does not write to the spare capacity of p.
+// 4. Write does not modify p.
 //
 // Use ImplementsWriterOpts for more control over the test suite.
-func ImplementsWriter(t *testing.T, writer io.Writer) bool {
+func ImplementsWriter(t testing.TB, writer io.Writer) bool {
 	return ImplementsWriterOpts(t, writer, defaultWriterOpts)
 }
 
 // WriterOpts defines fine tunes controls for the ImplementsWriterOpts test.
 type WriterOpts struct {
 	BufferSize int
+	// DetectRetention scribbles over p once Write returns, as Write must not retain p. Retention is detected through
+	// Readback.
+	DetectRetention bool
+	// Readback, if set, returns the bytes stored by the writer. They must equal the bytes written, after calling Flush
+	// if the writer has a Flush() error method.
+	Readback func() []byte
+	// ObserveModification compares p against a snapshot from a separate goroutine while Write runs, to catch
+	// implementations which modify p temporarily, for example by encoding in place and reverting.
+	ObserveModification bool
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
+	// StickyWriteError requires that once Write failed, every later call fails with the same error, matched using
+	// errors.Is. Many consumers assume this, as bufio.Writer does, but io.Writer does not require it. If unset, and
+	// the profile does not enforce the clause, whether it holds is logged.
+	StickyWriteError bool
+	// WriteString verifies properties 1 and 2 for WriteString as well, if the writer implements io.StringWriter. It
+	// writes another BufferSize bytes once the writes and Readback are verified, so the writer receives the content
+	// twice.
+	WriteString bool
 }
 
 // ImplementsWriterOpts uses providing options to perform ImplementsWriter.
-func ImplementsWriterOpts(t *testing.T, writer io.Writer, opts WriterOpts) bool {
-	var buf = make([]byte, opts.BufferSize)
+func ImplementsWriterOpts(t testing.TB, writer io.Writer, opts WriterOpts) bool {
+	return implementsWriter(t, "Write", writer, opts)
+}
+
+// implementsWriter performs ImplementsWriterOpts. op is the method being verified, which is Write, or WriteAt if
+// writer adapts an io.WriterAt.
+func implementsWriter(t testing.TB, op string, writer io.Writer, opts WriterOpts) bool {
+	var buf = guarded(opts.BufferSize)
+	fill(buf)
+
+	// WriteString is verified even if a call to Write failed, panicked or did not return, so that every failure is
+	// reported.
+	var ok = writeSequential(t, op, writer, buf, opts)
+	if sw, isStringWriter := writer.(io.StringWriter); isStringWriter && opts.WriteString {
+		ok = writeString(t, sw, buf, opts.Timeout) && ok
+	}
+	return ok
+}
+
+// writeSequential writes buf, verifying every call to Write, and verifies the bytes stored by the writer through
+// opts.Readback once buf is written. op is the method being verified, which writer calls.
+func writeSequential(t testing.TB, op string, writer io.Writer, buf []byte, opts WriterOpts) bool {
 	var n int
 	var err error
 
+	var retention = detect(t, opts.DetectRetention)
+	var modification = detect(t, opts.ObserveModification)
+	checking(t, clauseOf(op, "accept"))
+
 	for err == nil && n < opts.BufferSize {
 		var a int
 		chunk := buf[n:]
-		a, err = writer.Write(chunk)
+		if retention {
+			chunk = guarded(len(chunk))
+			copy(chunk, buf[n:])
+		}
+		var snapshot = append([]byte(nil), chunk...)
+		var observed = observe(modification, chunk, snapshot)
+		var returned = within(t, opts.Timeout, op, func() { a, err = writer.Write(chunk) }, "len(p)=%d", len(chunk))
+		// Stop the observer before any check can return.
+		var modified = observed()
+		if !returned {
+			return false
+		}
 		n += a
 
-		if !(assert.Greater(t, n, 0) && assert.LessOrEqual(t, n, opts.BufferSize)) {
+		if !(checkWrite(t, op, chunk, a, err) &&
+			checkGuard(t, op, chunk) &&
+			notModified(t, op, chunk, snapshot, modified)) {
 			return false
 		}
 
-		if n < len(chunk) {
-			return assert.Error(t, err)
+		if retention {
+			scribble(chunk)
 		}
+
+		switch {
+		case a < len(chunk) && err != nil:
+			return checkStickyWrite(t, op, writer, chunk[a:], err, opts.StickyWriteError, opts.Timeout)
+		case a == 0:
+			// A waived short write without an error, which would be repeated forever.
+			return true
+		}
+	}
+	if err != nil && !violated(t, clauseOf(op, "accept"), op+" failed", "after %d of %d bytes: %v", n, opts.BufferSize, err) {
+		return false
+	}
+	return opts.Readback == nil || readback(t, op, writer, opts.Readback, buf, opts.Timeout)
+}
+
+// checkWrite verifies that 0 <= n <= len(p) and that a short write returns an error for a single call to op, which is
+// Write, WriteString or WriteAt.
+func checkWrite(t testing.TB, op string, p []byte, n int, err error) bool {
+	checking(t, clauseOf(op, "count"), clauseOf(op, "short"))
+	if message := readViolation(len(p), n); message != "" {
+		return violated(t, clauseOf(op, "count"), op+" "+message)
+	}
+	if message := shortViolation(len(p), n, err); message != "" {
+		return violated(t, clauseOf(op, "short"), op+" "+message)
 	}
-	return assert.NoError(t, err) && assert.Equal(t, n, opts.BufferSize)
+	return true
 }
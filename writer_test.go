@@ -16,9 +16,13 @@
 package iosemantic_test
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"github.com/kaiserkarel/iosemantic"
+	"github.com/kaiserkarel/iosemantic/adversary"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"testing"
 )
 
@@ -31,3 +35,55 @@ func TestImplementsWriterOpts(t *testing.T) {
 	writer := bytes.NewBuffer(make([]byte, 4096*100))
 	assert.True(t, iosemantic.ImplementsWriterOpts(t, writer, iosemantic.WriterOpts{BufferSize: 201 * 1011}))
 }
+
+func TestImplementsWriterOptsRetention(t *testing.T) {
+	var writer bytes.Buffer
+	assert.True(t, iosemantic.ImplementsWriterOpts(t, &writer, iosemantic.WriterOpts{
+		BufferSize:      4096 * 100,
+		DetectRetention: true,
+		Readback:        writer.Bytes,
+	}))
+}
+
+func TestImplementsWriterOptsObserveModification(t *testing.T) {
+	var writer bytes.Buffer
+	assert.True(t, iosemantic.ImplementsWriterOpts(t, &writer, iosemantic.WriterOpts{
+		BufferSize:          4096 * 100,
+		ObserveModification: true,
+		Readback:            writer.Bytes,
+	}))
+}
+
+func TestImplementsWriterOptsStickyWriteError(t *testing.T) {
+	writer := bufio.NewWriter(adversary.FailingWriter(io.Discard, 1, errors.New("destination failed")))
+	assert.True(t, iosemantic.ImplementsWriterOpts(t, writer, iosemantic.WriterOpts{BufferSize: 4096 * 100, StickyWriteError: true}))
+}
+
+// shortStringWriter implements Write correctly, but reports short string writes without an error.
+type shortStringWriter struct {
+	bytes.Buffer
+}
+
+func (w *shortStringWriter) WriteString(s string) (int, error) {
+	n, err := w.Buffer.WriteString(s[:len(s)/2])
+	return n, err
+}
+
+func TestImplementsWriterOptsWriteString(t *testing.T) {
+	var writer bytes.Buffer
+	assert.True(t, iosemantic.ImplementsWriterOpts(t, &writer, iosemantic.WriterOpts{
+		BufferSize:  4096 * 100,
+		Readback:    writer.Bytes,
+		WriteString: true,
+	}))
+
+	var rec = &recorder{TB: t}
+	assert.True(t, iosemantic.ImplementsWriterOpts(rec, &shortStringWriter{}, iosemantic.WriterOpts{BufferSize: 4096}))
+	assert.False(t, iosemantic.ImplementsWriterOpts(rec, &shortStringWriter{}, iosemantic.WriterOpts{
+		BufferSize:  4096,
+		WriteString: true,
+	}))
+	if assert.Len(t, rec.messages, 1) {
+		assert.Contains(t, rec.messages[0], "[writer.short]")
+	}
+}
@@ -19,13 +19,14 @@ import (
 	"context"
 	"io"
 	"testing"
+	"time"
 
-	"github.com/stretchr/testify/assert"
 	"golang.org/x/sync/errgroup"
 )
 
 var defaultWriterAtOpts = WriterAtOpts{
 	BufferSize: 4096,
+	Timeout:    defaultTimeout,
 }
 
 // ImplementsWriterAt verifies the following properties for a writer:
@@ -33,36 +34,63 @@ var defaultWriterAtOpts = WriterAtOpts{
 // 1. 0 <= n <= len(p) where p is the buffer being written from.
 // 2. if n < len(p), err != nil.
 // 3. No error is returned during parallel WriteAt calls on the same destination if the ranges do not overlap.
+// 4. WriteAt does not write to the spare capacity of p.
+// 5. WriteAt does not modify p.
 //
 // Use ImplementsWriterAtOpts for more control over the test suite.
-func ImplementsWriterAt(t *testing.T, writer io.WriterAt, length int64) bool {
+func ImplementsWriterAt(t testing.TB, writer io.WriterAt, length int64) bool {
 	return ImplementsWriterAtOpts(t, writer, length, defaultWriterAtOpts)
 }
 
 // WriterAtOpts defines fine tunes controls for the ImplementsWriterAtOpts test.
 type WriterAtOpts struct {
 	BufferSize int
+	// DetectRetention scribbles over p once WriteAt returns, as WriteAt must not retain p. Retention is detected
+	// through Readback.
+	DetectRetention bool
+	// Readback, if set, returns the bytes stored by the writer from offset 0. They must equal the bytes written.
+	Readback func() []byte
+	// ObserveModification compares p against a snapshot from a separate goroutine while WriteAt runs, to catch
+	// implementations which modify p temporarily, for example by encoding in place and reverting.
+	ObserveModification bool
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
+	// StickyWriteError requires that once WriteAt failed, every later call fails with the same error, matched using
+	// errors.Is. Many consumers assume this, as bufio.Writer does, but io.WriterAt does not require it. If unset, and
+	// the profile does not enforce the clause, whether it holds is logged.
+	StickyWriteError bool
+	// WriteString is ignored, as io.WriterAt has no string counterpart. It keeps WriterAtOpts convertible to
+	// WriterOpts.
+	WriteString bool
 }
 
 // ImplementsWriterAtOpts uses providing options to perform ImplementsWriterAt.
-func ImplementsWriterAtOpts(t *testing.T, writer io.WriterAt, length int64, opts WriterAtOpts) bool {
-	if !ImplementsWriterOpts(t,
-		toWriter(writer, 0), WriterOpts(opts)) {
-		return false
-	}
+func ImplementsWriterAtOpts(t testing.TB, writer io.WriterAt, length int64, opts WriterAtOpts) bool {
+	// The parallel writes are verified even if the writer failed sequential writes, so that every failure is
+	// reported.
+	var ok = implementsWriter(t, "WriteAt", toWriter(writer, 0), WriterOpts(opts))
 
+	if length > 0 {
+		checking(t, WriterAtParallel)
+	}
 	grp, _ := errgroup.WithContext(context.Background())
 	for i := int64(0); i < length && i < 50; i++ {
 		i := i
 		grp.Go(func() error {
-			var buf = make([]byte, 1)
-			_, err := writer.WriteAt(buf, i)
-			assert.NoError(t, err)
-			return err
+			var buf = guarded(1)
+			var err error
+			if !within(t, opts.Timeout, "WriteAt", func() { _, err = writer.WriteAt(buf, i) }, "len(p)=1, off=%d", i) {
+				return errFailed
+			}
+			if !(noError(t, WriterAtParallel, err, "parallel WriteAt(len(p)=1, off=%d) failed", i) && checkGuard(t, "WriteAt", buf)) {
+				return errFailed
+			}
+			return nil
 		})
 	}
 
-	return assert.NoError(t, grp.Wait())
+	return grp.Wait() == nil && ok
 }
 
 type writer struct {
@@ -17,56 +17,56 @@ package iosemantic
 
 import (
 	"bytes"
-	"github.com/stretchr/testify/assert"
 	"io"
 	"testing"
 	"testing/iotest"
+	"time"
+
+	"github.com/kaiserkarel/iosemantic/adversary"
 )
 
 var defaultWriterToOpts = WriterToOpts{
 	BufferSize: 4096,
+	Timeout:    defaultTimeout,
 }
 
 // ImplementsWriterTo verifies the following properties for a reader:
 //
 // 1. The WriterTo writes to the writer until it is finished, or an error is encountered.
 // 2. Any error is returned.
+// 3. The returned count equals the number of bytes written.
 //
 // Use ImplementsWriterToOpts for more control over the test suite.
-func ImplementsWriterTo(t *testing.T, writer io.WriterTo) bool {
+func ImplementsWriterTo(t testing.TB, writer io.WriterTo) bool {
 	return ImplementsWriterToOpts(t, writer, defaultWriterToOpts)
 }
 
 // WriterToOpts defines fine tunes controls for the ImplementsWriterToOpts test.
 type WriterToOpts struct {
 	BufferSize int
+	// Timeout is the deadline of a single call into the implementation. When it expires, the check fails with a dump
+	// of all goroutines. Zero disables the deadline.
+	Timeout time.Duration
 }
 
 // ImplementsWriterToOpts uses providing options to perform ImplementsWriterTo.
-func ImplementsWriterToOpts(t *testing.T, writer io.WriterTo, opts WriterToOpts) bool {
-	src := &timoutWriter{
-		bytes.NewBuffer(make([]byte, opts.BufferSize)),
-		true}
-	n, err := writer.WriteTo(src)
-	assert.EqualError(t, err, iotest.ErrTimeout.Error())
-	assert.Zero(t, n)
-
-	n, err = writer.WriteTo(src)
-	return assert.NoError(t, err) &&
-		assert.NotZero(t, opts.BufferSize, int(n), "expected at least a write gte zero, but actually wrote: %d", n)
-}
-
-// timeOutWriter resembles iotest.TimeoutReader.
-type timoutWriter struct {
-	writer io.Writer
-	err    bool
-}
+func ImplementsWriterToOpts(t testing.TB, writer io.WriterTo, opts WriterToOpts) bool {
+	dst := bytes.NewBuffer(make([]byte, opts.BufferSize))
+	src := adversary.TimeoutWriter(dst)
+	var n int64
+	var err error
+	if !within(t, opts.Timeout, "WriteTo", func() { n, err = writer.WriteTo(src) }, "%T", src) {
+		return false
+	}
+	if !(checkIs(t, WriterToError, err, iotest.ErrTimeout, "expected the error of the destination, got %v", err) &&
+		holds(t, WriterToCount, n == 0, "WriteTo returned %d for a destination writing nothing", n)) {
+		return false
+	}
 
-// Write buffer p to underlying writer. If this is the first write, return ErrTimeout.
-func (t *timoutWriter) Write(p []byte) (int, error) {
-	if t.err {
-		t.err = false
-		return 0, iotest.ErrTimeout
+	if !within(t, opts.Timeout, "WriteTo", func() { n, err = writer.WriteTo(src) }, "%T", src) {
+		return false
 	}
-	return t.writer.Write(p)
+	return noError(t, WriterToError, err, "WriteTo failed after the destination recovered") &&
+		holds(t, WriterToCount, int64(dst.Len()-opts.BufferSize) == n,
+			"WriteTo returned %d, a count different from the %d bytes written", n, dst.Len()-opts.BufferSize)
 }